@@ -0,0 +1,398 @@
+package geminix
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type CostBasisMethod int
+
+const (
+	FIFO CostBasisMethod = iota
+	LIFO
+	AverageCost
+)
+
+func (m CostBasisMethod) String() string {
+	switch m {
+	case FIFO:
+		return "FIFO"
+	case LIFO:
+		return "LIFO"
+	case AverageCost:
+		return "AverageCost"
+	}
+	return fmt.Sprintf("CostBasisMethod(%d)", int(m))
+}
+
+var ErrNoValuer = errors.New("geminix: a valuer is required to convert non-fiat amounts")
+
+type Lot struct {
+	Amount    float64
+	UnitCost  float64
+	Timestamp time.Time
+}
+
+type Position struct {
+	Currency    Currency
+	Amount      float64
+	CostBasis   float64
+	RealizedPnL float64
+	Proceeds    float64
+	Fees        float64
+	// Unmatched is the amount disposed of without any lot to match it
+	// against, usually because the history does not go back far enough.
+	// It is treated as having a zero cost basis.
+	Unmatched float64
+}
+
+func (p Position) AverageCost() float64 {
+	if p.Amount == 0 {
+		return 0
+	}
+	return p.CostBasis / p.Amount
+}
+
+// Ledger computes cost basis and realized PnL per currency from trade and
+// transfer history. All values are expressed in the fiat reporting currency;
+// amounts in other currencies are converted with the valuer.
+type Ledger struct {
+	method    CostBasisMethod
+	fiat      Currency
+	valuer    Valuer
+	lots      map[Currency][]Lot
+	positions map[Currency]*Position
+}
+
+func NewLedger(method CostBasisMethod, fiat Currency, valuer Valuer) *Ledger {
+	return &Ledger{
+		method:    method,
+		fiat:      fiat,
+		valuer:    valuer,
+		lots:      map[Currency][]Lot{},
+		positions: map[Currency]*Position{},
+	}
+}
+
+func (l *Ledger) position(currency Currency) *Position {
+	position, ok := l.positions[currency]
+	if !ok {
+		position = &Position{Currency: currency}
+		l.positions[currency] = position
+	}
+	return position
+}
+
+func (l *Ledger) value(currency Currency, amount float64, at time.Time) (float64, error) {
+	if currency == l.fiat {
+		return amount, nil
+	}
+	if l.valuer == nil {
+		return 0, ErrNoValuer
+	}
+	return l.valuer.Value(currency, amount, at)
+}
+
+func (l *Ledger) isFiat(currency Currency) bool {
+	switch currency {
+	case l.fiat, USD, EUR, GBP, SGD:
+		return true
+	}
+	return false
+}
+
+func (l *Ledger) acquire(currency Currency, amount float64, cost float64, at time.Time) {
+	if amount <= 0 {
+		return
+	}
+
+	position := l.position(currency)
+	position.Amount += amount
+	position.CostBasis += cost
+
+	if l.method == AverageCost {
+		lots := l.lots[currency]
+		if len(lots) == 0 {
+			l.lots[currency] = []Lot{{Amount: amount, UnitCost: cost / amount, Timestamp: at}}
+			return
+		}
+
+		lot := &lots[0]
+		total := lot.Amount + amount
+		lot.UnitCost = (lot.Amount*lot.UnitCost + cost) / total
+		lot.Amount = total
+		return
+	}
+
+	l.lots[currency] = append(l.lots[currency], Lot{Amount: amount, UnitCost: cost / amount, Timestamp: at})
+}
+
+// remove takes amount out of the lots according to the cost basis method and
+// returns the cost of what was removed.
+func (l *Ledger) remove(currency Currency, amount float64) float64 {
+	position := l.position(currency)
+	lots := l.lots[currency]
+
+	var cost float64
+	for amount > 0 && len(lots) > 0 {
+		i := 0
+		if l.method == LIFO {
+			i = len(lots) - 1
+		}
+
+		lot := &lots[i]
+		take := amount
+		if lot.Amount < take {
+			take = lot.Amount
+		}
+
+		cost += take * lot.UnitCost
+		lot.Amount -= take
+		amount -= take
+
+		if lot.Amount <= 0 {
+			lots = append(lots[:i], lots[i+1:]...)
+		}
+	}
+
+	l.lots[currency] = lots
+
+	position.Unmatched += amount
+	position.Amount = 0
+	position.CostBasis = 0
+	for _, lot := range lots {
+		position.Amount += lot.Amount
+		position.CostBasis += lot.Amount * lot.UnitCost
+	}
+
+	return cost
+}
+
+func (l *Ledger) dispose(currency Currency, amount float64, proceeds float64) {
+	if amount <= 0 {
+		return
+	}
+
+	cost := l.remove(currency, amount)
+
+	position := l.position(currency)
+	position.Proceeds += proceeds
+	position.RealizedPnL += proceeds - cost
+}
+
+func (l *Ledger) AddTrade(trade Trade) error {
+	base, quote := Symbol(trade.Symbol).Split()
+	if base == "" {
+		return fmt.Errorf("geminix: unknown symbol %q", trade.Symbol)
+	}
+
+	at := time.Unix(0, int64(trade.Timestampms)*int64(time.Millisecond))
+
+	price, err := ParseAmount(trade.Price)
+	if err != nil {
+		return err
+	}
+
+	amount, err := ParseAmount(trade.Amount)
+	if err != nil {
+		return err
+	}
+
+	feeAmount, err := ParseAmount(trade.FeeAmount)
+	if err != nil {
+		return err
+	}
+	feeCurrency := Currency(strings.ToUpper(trade.FeeCurrency))
+
+	gross, err := l.value(quote, price*amount, at)
+	if err != nil {
+		return err
+	}
+
+	var fee float64
+	if feeAmount != 0 {
+		fee, err = l.value(feeCurrency, feeAmount, at)
+		if err != nil {
+			return err
+		}
+	}
+
+	// A fee charged in a crypto quote currency comes out of the quote
+	// position on top of the notional.
+	var quoteFee, quoteFeeValue float64
+	if feeCurrency == quote && !l.isFiat(quote) {
+		quoteFee, quoteFeeValue = feeAmount, fee
+	}
+
+	switch strings.ToLower(trade.Type) {
+	case "buy":
+		if feeCurrency == base {
+			l.acquire(base, amount-feeAmount, gross, at)
+		} else {
+			l.acquire(base, amount, gross+fee, at)
+		}
+		if !l.isFiat(quote) {
+			l.dispose(quote, price*amount+quoteFee, gross+quoteFeeValue)
+		}
+	case "sell":
+		if feeCurrency == base {
+			l.dispose(base, amount, gross)
+			l.dispose(base, feeAmount, 0)
+		} else {
+			l.dispose(base, amount, gross-fee)
+		}
+		if !l.isFiat(quote) {
+			l.acquire(quote, price*amount-quoteFee, gross-quoteFeeValue, at)
+		}
+	default:
+		return fmt.Errorf("geminix: unknown trade type %q", trade.Type)
+	}
+
+	l.position(base).Fees += fee
+
+	return nil
+}
+
+// AddTransfer records deposits as acquisitions at their fiat value at the
+// time of the deposit, and withdrawals as removals that realize no PnL.
+// Fiat transfers and transfers that have not completed are ignored.
+func (l *Ledger) AddTransfer(transfer Transfer) error {
+	currency := Currency(strings.ToUpper(string(transfer.Currency)))
+	if l.isFiat(currency) {
+		return nil
+	}
+
+	switch transfer.Status {
+	case "Complete", "Advanced":
+	default:
+		return nil
+	}
+
+	at := time.Unix(0, int64(transfer.Timestampms)*int64(time.Millisecond))
+
+	amount, err := ParseAmount(transfer.Amount)
+	if err != nil {
+		return err
+	}
+
+	switch transfer.Type {
+	case "Deposit":
+		cost, err := l.value(currency, amount, at)
+		if err != nil {
+			return err
+		}
+		l.acquire(currency, amount, cost, at)
+	case "Withdrawal":
+		l.remove(currency, amount)
+	}
+
+	return nil
+}
+
+// Apply records trades and transfers in chronological order.
+func (l *Ledger) Apply(trades []Trade, transfers []Transfer) error {
+	type event struct {
+		timestampms uint64
+		trade       *Trade
+		transfer    *Transfer
+	}
+
+	events := make([]event, 0, len(trades)+len(transfers))
+	for i := range trades {
+		events = append(events, event{timestampms: trades[i].Timestampms, trade: &trades[i]})
+	}
+	for i := range transfers {
+		events = append(events, event{timestampms: transfers[i].Timestampms, transfer: &transfers[i]})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].timestampms < events[j].timestampms })
+
+	for _, e := range events {
+		var err error
+		if e.trade != nil {
+			err = l.AddTrade(*e.trade)
+		} else {
+			err = l.AddTransfer(*e.transfer)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *Ledger) Position(currency Currency) Position {
+	if position, ok := l.positions[currency]; ok {
+		return *position
+	}
+	return Position{Currency: currency}
+}
+
+func (l *Ledger) Positions() []Position {
+	positions := make([]Position, 0, len(l.positions))
+	for _, position := range l.positions {
+		positions = append(positions, *position)
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i].Currency < positions[j].Currency })
+	return positions
+}
+
+func (l *Ledger) Lots(currency Currency) []Lot {
+	return append([]Lot(nil), l.lots[currency]...)
+}
+
+type PnLLine struct {
+	Position
+	MarketValue   float64
+	UnrealizedPnL float64
+}
+
+type PnLReport struct {
+	Fiat          Currency
+	Method        CostBasisMethod
+	At            time.Time
+	Lines         []PnLLine
+	RealizedPnL   float64
+	UnrealizedPnL float64
+	Fees          float64
+}
+
+// Report values the open positions with the given valuer to compute
+// unrealized PnL alongside the realized PnL recorded so far. A nil valuer
+// falls back to the ledger's own.
+func (l *Ledger) Report(valuer Valuer, at time.Time) (PnLReport, error) {
+	report := PnLReport{Fiat: l.fiat, Method: l.method, At: at}
+
+	if valuer == nil {
+		valuer = l.valuer
+	}
+
+	for _, position := range l.Positions() {
+		line := PnLLine{Position: position}
+
+		if position.Amount > 0 {
+			if valuer == nil {
+				return report, ErrNoValuer
+			}
+
+			marketValue, err := valuer.Value(position.Currency, position.Amount, at)
+			if err != nil {
+				return report, err
+			}
+
+			line.MarketValue = marketValue
+			line.UnrealizedPnL = marketValue - position.CostBasis
+		}
+
+		report.Lines = append(report.Lines, line)
+		report.RealizedPnL += line.RealizedPnL
+		report.UnrealizedPnL += line.UnrealizedPnL
+		report.Fees += line.Fees
+	}
+
+	return report, nil
+}
@@ -0,0 +1,538 @@
+package geminix
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var currencyNetworks = map[Currency]Network{
+	BTC:  Bitcoin,
+	BCH:  BitcoinCash,
+	LTC:  Litecoin,
+	ZEC:  ZCash,
+	FIL:  Filecoin,
+	DOGE: Dogecoin,
+
+	// ERC-20 tokens
+	ETH: Ethereum, BAT: Ethereum, DAI: Ethereum, LINK: Ethereum, OXT: Ethereum, COMP: Ethereum,
+	PAXG: Ethereum, MKR: Ethereum, ZRX: Ethereum, KNC: Ethereum, MANA: Ethereum, STORJ: Ethereum,
+	SNX: Ethereum, CRV: Ethereum, BAL: Ethereum, UNI: Ethereum, REN: Ethereum, UMA: Ethereum,
+	YFI: Ethereum, AAVE: Ethereum, SKL: Ethereum, GRT: Ethereum, BNT: Ethereum, ONEINCH: Ethereum,
+	ENJ: Ethereum, LRC: Ethereum, SAND: Ethereum, CUBE: Ethereum, LPT: Ethereum, BOND: Ethereum,
+	MATIC: Ethereum, INJ: Ethereum, SUSHI: Ethereum,
+}
+
+// Network returns the network the currency is withdrawn on. It is empty for
+// fiat and for currencies whose network is not known.
+func (c Currency) Network() Network {
+	return currencyNetworks[Currency(strings.ToUpper(string(c)))]
+}
+
+// AddressError describes why an address was rejected locally.
+type AddressError struct {
+	Network Network
+	Address string
+	Reason  string
+}
+
+func (e *AddressError) Error() string {
+	return fmt.Sprintf("geminix: invalid %s address %q: %s", e.Network, e.Address, e.Reason)
+}
+
+// AddressValidators holds the validator for each network. Validators return
+// a reason the address is invalid, or an empty string. Only mainnet
+// encodings are accepted; see TestnetAddressValidators.
+var AddressValidators = map[Network]func(address string) string{
+	Bitcoin:     validateBitcoin,
+	Ethereum:    validateEthereum,
+	BitcoinCash: validateBitcoinCash,
+	Litecoin:    validateLitecoin,
+	ZCash:       validateZCash,
+	Filecoin:    validateFilecoin,
+	Dogecoin:    validateDogecoin,
+}
+
+// TestnetAddressValidators holds the validator for each network's testnet
+// and regtest encodings, which the sandbox withdraws to.
+var TestnetAddressValidators = map[Network]func(address string) string{
+	Bitcoin:     validateBitcoinTestnet,
+	Ethereum:    validateEthereum,
+	BitcoinCash: validateBitcoinCashTestnet,
+	Litecoin:    validateLitecoinTestnet,
+	ZCash:       validateZCashTestnet,
+	Filecoin:    validateFilecoinTestnet,
+	Dogecoin:    validateDogecoinTestnet,
+}
+
+// ValidateAddress checks a mainnet address's encoding and checksum for the
+// network. It returns an *AddressError if the address is malformed or the
+// network has no validator.
+func ValidateAddress(network Network, address string) error {
+	return validateAddress(AddressValidators, network, address)
+}
+
+// ValidateTestnetAddress is ValidateAddress for testnet addresses.
+func ValidateTestnetAddress(network Network, address string) error {
+	return validateAddress(TestnetAddressValidators, network, address)
+}
+
+// ValidateAddress checks an address with ValidateTestnetAddress if the
+// client uses the sandbox, and ValidateAddress otherwise.
+func (c *Client) ValidateAddress(network Network, address string) error {
+	return validateAddress(c.addressValidators(), network, address)
+}
+
+func (c *Client) addressValidators() map[Network]func(address string) string {
+	if c.url == SandboxBaseUrl {
+		return TestnetAddressValidators
+	}
+	return AddressValidators
+}
+
+func validateAddress(validators map[Network]func(address string) string, network Network, address string) error {
+	validator, ok := validators[network]
+	if !ok {
+		return &AddressError{Network: network, Address: address, Reason: "no validator for network"}
+	}
+
+	if reason := validator(address); reason != "" {
+		return &AddressError{Network: network, Address: address, Reason: reason}
+	}
+
+	return nil
+}
+
+func validateBitcoin(address string) string {
+	return validateBitcoinStyle(address, []string{"bc"}, 0x00, 0x05)
+}
+
+func validateBitcoinTestnet(address string) string {
+	return validateBitcoinStyle(address, []string{"tb", "bcrt"}, 0x6f, 0xc4)
+}
+
+func validateLitecoin(address string) string {
+	return validateBitcoinStyle(address, []string{"ltc"}, 0x30, 0x32, 0x05)
+}
+
+func validateLitecoinTestnet(address string) string {
+	return validateBitcoinStyle(address, []string{"tltc", "rltc"}, 0x6f, 0x3a, 0xc4)
+}
+
+// validateBitcoinStyle accepts segwit addresses with one of hrps and
+// base58check addresses with one of versions.
+func validateBitcoinStyle(address string, hrps []string, versions ...byte) string {
+	for _, hrp := range hrps {
+		if hasBech32Prefix(address, hrp+"1") {
+			return validateSegwit(address, hrps...)
+		}
+	}
+	return validateBase58Check(address, versions...)
+}
+
+func validateDogecoin(address string) string {
+	return validateBase58Check(address, 0x1e, 0x16)
+}
+
+func validateDogecoinTestnet(address string) string {
+	return validateBase58Check(address, 0x71, 0xc4)
+}
+
+func validateBitcoinCash(address string) string {
+	return validateBitcoinCashStyle(address, []string{"bitcoincash"}, 0x00, 0x05)
+}
+
+func validateBitcoinCashTestnet(address string) string {
+	return validateBitcoinCashStyle(address, []string{"bchtest", "bchreg"}, 0x6f, 0xc4)
+}
+
+// validateBitcoinCashStyle accepts cashaddr addresses with one of prefixes,
+// the first of which is assumed when there is none, and legacy addresses
+// with one of versions.
+func validateBitcoinCashStyle(address string, prefixes []string, versions ...byte) string {
+	lower := strings.ToLower(address)
+	if strings.Contains(lower, ":") || lower != "" && (lower[0] == 'q' || lower[0] == 'p') {
+		return validateCashAddr(address, prefixes...)
+	}
+	return validateBase58Check(address, versions...)
+}
+
+// validateZCash accepts transparent addresses, which have two version bytes.
+func validateZCash(address string) string {
+	return validateZCashStyle(address, 0x1cb8, 0x1cbd)
+}
+
+func validateZCashTestnet(address string) string {
+	return validateZCashStyle(address, 0x1d25, 0x1cba)
+}
+
+func validateZCashStyle(address string, versions ...uint16) string {
+	decoded, reason := base58CheckDecode(address)
+	if reason != "" {
+		return reason
+	}
+	if len(decoded) != 22 {
+		return "wrong length"
+	}
+
+	version := uint16(decoded[0])<<8 | uint16(decoded[1])
+	for _, v := range versions {
+		if version == v {
+			return ""
+		}
+	}
+	return "not a transparent address"
+}
+
+func validateBase58Check(address string, versions ...byte) string {
+	decoded, reason := base58CheckDecode(address)
+	if reason != "" {
+		return reason
+	}
+	if len(decoded) != 21 {
+		return "wrong length"
+	}
+	if bytes.IndexByte(versions, decoded[0]) < 0 {
+		return "unknown version byte"
+	}
+	return ""
+}
+
+// validateEthereum checks the EIP-55 checksum of mixed-case addresses. All
+// lower or upper case addresses carry no checksum.
+func validateEthereum(address string) string {
+	if !strings.HasPrefix(address, "0x") || len(address) != 42 {
+		return "expected 0x followed by 40 hex digits"
+	}
+
+	digits := address[2:]
+	if _, err := hex.DecodeString(digits); err != nil {
+		return "expected 0x followed by 40 hex digits"
+	}
+
+	if digits == strings.ToLower(digits) || digits == strings.ToUpper(digits) {
+		return ""
+	}
+
+	hash := keccak256([]byte(strings.ToLower(digits)))
+	for i, r := range digits {
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0x0f
+		}
+
+		if r >= 'a' && r <= 'f' && nibble >= 8 || r >= 'A' && r <= 'F' && nibble < 8 {
+			return "bad EIP-55 checksum"
+		}
+	}
+
+	return ""
+}
+
+var filecoinEncoding = base32.NewEncoding(base32Alphabet).WithPadding(base32.NoPadding)
+
+// validateFilecoin accepts ID, secp256k1, actor and BLS addresses.
+func validateFilecoin(address string) string {
+	return validateFilecoinStyle(address, 'f')
+}
+
+func validateFilecoinTestnet(address string) string {
+	return validateFilecoinStyle(address, 't')
+}
+
+func validateFilecoinStyle(address string, network byte) string {
+	if len(address) < 3 || address[0] != network {
+		return fmt.Sprintf("expected %c network prefix", network)
+	}
+
+	protocol := address[1]
+	payload := address[2:]
+
+	var size int
+	switch protocol {
+	case '0':
+		if len(payload) > 20 {
+			return "id too long"
+		}
+		if _, err := strconv.ParseUint(payload, 10, 64); err != nil {
+			return "invalid id"
+		}
+		return ""
+	case '1', '2':
+		size = 20
+	case '3':
+		size = 48
+	default:
+		return "unknown protocol"
+	}
+
+	decoded, err := filecoinEncoding.DecodeString(payload)
+	if err != nil {
+		return "invalid base32"
+	}
+	if len(decoded) != size+4 {
+		return "wrong length"
+	}
+
+	checksum := blake2b(append([]byte{protocol - '0'}, decoded[:size]...), 4)
+	if !bytes.Equal(checksum, decoded[size:]) {
+		return "bad checksum"
+	}
+
+	return ""
+}
+
+const (
+	base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	bech32Alphabet = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	base32Alphabet = "abcdefghijklmnopqrstuvwxyz234567"
+)
+
+func base58Decode(s string) ([]byte, bool) {
+	var decoded []byte
+
+	for _, r := range s {
+		digit := strings.IndexRune(base58Alphabet, r)
+		if digit < 0 {
+			return nil, false
+		}
+
+		carry := digit
+		for i := len(decoded) - 1; i >= 0; i-- {
+			carry += int(decoded[i]) * 58
+			decoded[i] = byte(carry)
+			carry >>= 8
+		}
+		for carry > 0 {
+			decoded = append([]byte{byte(carry)}, decoded...)
+			carry >>= 8
+		}
+	}
+
+	// Each leading 1 encodes a leading zero byte.
+	zeros := len(s) - len(strings.TrimLeft(s, "1"))
+
+	return append(make([]byte, zeros), decoded...), true
+}
+
+// base58CheckDecode returns the payload of a base58check string without its
+// checksum.
+func base58CheckDecode(s string) ([]byte, string) {
+	decoded, ok := base58Decode(s)
+	if !ok {
+		return nil, "invalid base58 character"
+	}
+	if len(decoded) < 5 {
+		return nil, "too short"
+	}
+
+	payload := decoded[:len(decoded)-4]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], decoded[len(decoded)-4:]) {
+		return nil, "bad checksum"
+	}
+
+	return payload, ""
+}
+
+func hasBech32Prefix(address string, prefixes ...string) bool {
+	lower := strings.ToLower(address)
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// convertBits regroups 5-bit groups into bytes, rejecting non-zero padding.
+func convertBits(data []byte, from uint, to uint) ([]byte, bool) {
+	var acc, bitCount uint
+	var out []byte
+
+	for _, value := range data {
+		acc = acc<<from | uint(value)
+		bitCount += from
+		for bitCount >= to {
+			bitCount -= to
+			out = append(out, byte(acc>>bitCount&(1<<to-1)))
+		}
+	}
+
+	if bitCount >= from || acc&(1<<bitCount-1) != 0 {
+		return nil, false
+	}
+
+	return out, true
+}
+
+// decodeCharset maps each character to its index in the bech32 alphabet.
+// Mixed case strings are rejected.
+func decodeCharset(s string) ([]byte, bool) {
+	lower := strings.ToLower(s)
+	if lower != s && strings.ToUpper(s) != s {
+		return nil, false
+	}
+
+	values := make([]byte, len(lower))
+	for i, r := range lower {
+		j := strings.IndexRune(bech32Alphabet, r)
+		if j < 0 {
+			return nil, false
+		}
+		values[i] = byte(j)
+	}
+
+	return values, true
+}
+
+const (
+	bech32Constant  = 1
+	bech32mConstant = 0x2bc830a3
+)
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if top>>i&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+
+	return chk
+}
+
+// validateSegwit checks a BIP 173 or BIP 350 witness address.
+func validateSegwit(address string, hrps ...string) string {
+	if len(address) > 90 {
+		return "too long"
+	}
+
+	// The whole address, hrp included, must be in one case.
+	lower := strings.ToLower(address)
+	if address != lower && address != strings.ToUpper(address) {
+		return "mixed case"
+	}
+
+	separator := strings.LastIndex(lower, "1")
+	if separator < 1 || len(lower)-separator-1 < 7 {
+		return "missing separator or checksum"
+	}
+
+	hrp := lower[:separator]
+	if !contains(hrps, hrp) {
+		return "unknown human-readable part"
+	}
+
+	data, ok := decodeCharset(address[separator+1:])
+	if !ok {
+		return "invalid bech32 character"
+	}
+
+	var values []byte
+	for _, r := range hrp {
+		values = append(values, byte(r>>5))
+	}
+	values = append(values, 0)
+	for _, r := range hrp {
+		values = append(values, byte(r&31))
+	}
+	values = append(values, data...)
+
+	version := data[0]
+	constant := uint32(bech32Constant)
+	if version > 0 {
+		constant = bech32mConstant
+	}
+	if version > 16 {
+		return "invalid witness version"
+	}
+	if bech32Polymod(values) != constant {
+		return "bad checksum"
+	}
+
+	program, ok := convertBits(data[1:len(data)-6], 5, 8)
+	if !ok || len(program) < 2 || len(program) > 40 {
+		return "invalid witness program"
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return "invalid witness program length"
+	}
+
+	return ""
+}
+
+func cashAddrPolymod(values []byte) uint64 {
+	generator := [5]uint64{0x98f2bc8e61, 0x79b76d99e2, 0xf33e5fb3c4, 0xae2eabe2a8, 0x1e4f43e470}
+
+	c := uint64(1)
+	for _, v := range values {
+		top := c >> 35
+		c = (c&0x07ffffffff)<<5 ^ uint64(v)
+		for i := 0; i < 5; i++ {
+			if top>>i&1 == 1 {
+				c ^= generator[i]
+			}
+		}
+	}
+
+	return c ^ 1
+}
+
+// validateCashAddr checks a Bitcoin Cash address with one of prefixes, or
+// without a prefix, when the first is assumed.
+func validateCashAddr(address string, prefixes ...string) string {
+	if address != strings.ToLower(address) && address != strings.ToUpper(address) {
+		return "mixed case"
+	}
+
+	prefix, payload := prefixes[0], address
+	if i := strings.Index(address, ":"); i >= 0 {
+		prefix, payload = strings.ToLower(address[:i]), address[i+1:]
+	}
+	if !contains(prefixes, prefix) {
+		return "unknown prefix"
+	}
+
+	data, ok := decodeCharset(payload)
+	if !ok {
+		return "invalid cashaddr character"
+	}
+	if len(data) < 9 {
+		return "too short"
+	}
+
+	var values []byte
+	for _, r := range prefix {
+		values = append(values, byte(r&31))
+	}
+	values = append(values, 0)
+	values = append(values, data...)
+
+	if cashAddrPolymod(values) != 0 {
+		return "bad checksum"
+	}
+
+	decoded, ok := convertBits(data[:len(data)-8], 5, 8)
+	if !ok || len(decoded) < 1 {
+		return "invalid payload"
+	}
+
+	sizes := [8]int{20, 24, 28, 32, 40, 48, 56, 64}
+	if version := decoded[0]; version&0x80 != 0 || len(decoded)-1 != sizes[version&7] {
+		return "invalid version or hash length"
+	}
+
+	return ""
+}
@@ -0,0 +1,17 @@
+package geminix
+
+import "strconv"
+
+// ParseAmount parses a decimal string as returned by the API. Empty strings
+// are treated as zero.
+func ParseAmount(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseFloat(s, 64)
+}
+
+func FormatAmount(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
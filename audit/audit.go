@@ -0,0 +1,300 @@
+// Package audit keeps a tamper-evident record of the private requests made
+// by a geminix.Client.
+//
+// The log is JSON lines. Each line holds an entry and the SHA-256 of the
+// entry's exact bytes, and each entry holds the hash of the entry before it,
+// so editing, removing or reordering lines breaks the chain from that point
+// on. Verify checks a log. Lines removed from the end leave a valid chain,
+// so keep the latest hash from Head somewhere the log's writer cannot alter.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	geminix "github.com/Haakam21/gemini-exchange-go"
+)
+
+const Redacted = "REDACTED"
+
+type Entry struct {
+	Sequence uint64                 `json:"seq"`
+	Time     time.Time              `json:"time"`
+	Nonce    interface{}            `json:"nonce,omitempty"`
+	Endpoint string                 `json:"endpoint"`
+	Uri      string                 `json:"uri"`
+	Params   map[string]interface{} `json:"params,omitempty"`
+	Status   int                    `json:"status,omitempty"`
+	Outcome  string                 `json:"outcome"`
+	Error    string                 `json:"error,omitempty"`
+	Request  uint64                 `json:"request,omitempty"`
+	PrevHash string                 `json:"prev_hash"`
+}
+
+type line struct {
+	Entry json.RawMessage `json:"entry"`
+	Hash  string          `json:"hash"`
+}
+
+// Log appends entries to a file. Once a write fails, the log refuses every
+// further private request rather than let it go unrecorded.
+//
+// Each request is recorded twice: an entry with outcome "pending" before it
+// is sent, and an entry with its outcome after, whose Request is the
+// sequence number of the pending entry. A pending entry without an outcome
+// is a request whose result is unknown, such as when the process died or
+// the second write failed.
+type Log struct {
+	// Redact lists parameters whose values are replaced with Redacted.
+	Redact map[string]bool
+
+	// Now defaults to time.Now.
+	Now func() time.Time
+
+	mutex    sync.Mutex
+	file     *os.File
+	sequence uint64
+	prevHash string
+	err      error
+}
+
+// Open opens or creates the log at path. An existing log is verified and new
+// entries continue its chain.
+func Open(path string) (*Log, error) {
+	l := &Log{Redact: map[string]bool{}, Now: time.Now}
+
+	existing, err := os.Open(path)
+	if err == nil {
+		l.sequence, l.prevHash, err = verify(existing)
+		existing.Close()
+		if err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	l.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (l *Log) Close() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.file.Close()
+}
+
+// Head returns the sequence number and hash of the last entry.
+func (l *Log) Head() (uint64, string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.sequence, l.prevHash
+}
+
+// Err returns the write error that stopped the log, if any.
+func (l *Log) Err() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.err
+}
+
+// Interceptor records every POST request, which are the private requests.
+// A request is not sent if its pending entry cannot be written.
+func (l *Log) Interceptor() geminix.Interceptor {
+	return func(req *geminix.RequestInfo, next geminix.Handler) (*geminix.ResponseInfo, error) {
+		if req.Verb != "POST" {
+			return next(req)
+		}
+
+		pending := Entry{
+			Endpoint: geminix.Endpoint(req.Uri),
+			Uri:      req.Uri,
+			Params:   l.redact(req.Params),
+			Outcome:  "pending",
+		}
+		sequence, err := l.append(pending)
+		if err != nil {
+			return nil, fmt.Errorf("audit: log unavailable: %v", err)
+		}
+
+		res, err := next(req)
+
+		entry := Entry{
+			Endpoint: pending.Endpoint,
+			Uri:      req.Uri,
+			Nonce:    req.Params["nonce"],
+			Outcome:  "ok",
+			Request:  sequence,
+		}
+		if res != nil {
+			entry.Status = res.Status
+		}
+
+		var apiErr *geminix.ApiError
+		if errors.As(err, &apiErr) {
+			entry.Outcome = apiErr.Reason
+			entry.Error = apiErr.Message
+		} else if err != nil {
+			entry.Outcome = "error"
+			entry.Error = err.Error()
+		}
+
+		// The request has been sent, so its result is returned even if
+		// the outcome cannot be written. The log then refuses further
+		// requests.
+		l.Append(entry)
+
+		return res, err
+	}
+}
+
+func (l *Log) redact(params map[string]interface{}) map[string]interface{} {
+	redacted := map[string]interface{}{}
+	for key, value := range params {
+		switch {
+		case key == "nonce" || key == "request":
+		case l.Redact[key]:
+			redacted[key] = Redacted
+		default:
+			if v, ok := value.(*string); ok && v == nil {
+				continue
+			}
+			if v, ok := value.(*uint); ok && v == nil {
+				continue
+			}
+			if value != nil {
+				redacted[key] = value
+			}
+		}
+	}
+	return redacted
+}
+
+// Append adds an entry to the log, filling in its sequence number, time and
+// previous hash.
+func (l *Log) Append(entry Entry) error {
+	_, err := l.append(entry)
+	return err
+}
+
+func (l *Log) append(entry Entry) (uint64, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.err != nil {
+		return 0, l.err
+	}
+
+	l.err = l.write(entry)
+	return l.sequence, l.err
+}
+
+func (l *Log) write(entry Entry) error {
+	entry.Sequence = l.sequence + 1
+	entry.Time = l.Now().UTC()
+	entry.PrevHash = l.prevHash
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256(data)
+	record, err := json.Marshal(line{Entry: data, Hash: hex.EncodeToString(hash[:])})
+	if err != nil {
+		return err
+	}
+
+	_, err = l.file.Write(append(record, '\n'))
+	if err != nil {
+		return err
+	}
+
+	err = l.file.Sync()
+	if err != nil {
+		return err
+	}
+
+	l.sequence = entry.Sequence
+	l.prevHash = hex.EncodeToString(hash[:])
+
+	return nil
+}
+
+// VerifyError reports the first line at which a log's chain is broken.
+type VerifyError struct {
+	Line   int
+	Reason string
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("audit: line %d: %s", e.Line, e.Reason)
+}
+
+// Verify checks every entry's hash and its link to the entry before, and
+// returns the number of entries.
+func Verify(r io.Reader) (int, error) {
+	sequence, _, err := verify(r)
+	return int(sequence), err
+}
+
+func VerifyFile(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	return Verify(file)
+}
+
+func verify(r io.Reader) (uint64, string, error) {
+	var sequence uint64
+	var prevHash string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for n := 1; scanner.Scan(); n++ {
+		var l line
+		if err := json.Unmarshal(scanner.Bytes(), &l); err != nil {
+			return sequence, prevHash, &VerifyError{Line: n, Reason: "malformed line"}
+		}
+
+		hash := sha256.Sum256(l.Entry)
+		if hex.EncodeToString(hash[:]) != l.Hash {
+			return sequence, prevHash, &VerifyError{Line: n, Reason: "entry does not match its hash"}
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(l.Entry, &entry); err != nil {
+			return sequence, prevHash, &VerifyError{Line: n, Reason: "malformed entry"}
+		}
+		if entry.PrevHash != prevHash {
+			return sequence, prevHash, &VerifyError{Line: n, Reason: "chain broken: previous hash does not match"}
+		}
+		if entry.Sequence != sequence+1 {
+			return sequence, prevHash, &VerifyError{Line: n, Reason: fmt.Sprintf("expected sequence %d, got %d", sequence+1, entry.Sequence)}
+		}
+
+		sequence = entry.Sequence
+		prevHash = l.Hash
+	}
+
+	return sequence, prevHash, scanner.Err()
+}
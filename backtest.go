@@ -0,0 +1,346 @@
+package geminix
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// SlippageModel turns a reference price into the bid and ask that simulated
+// orders trade against.
+type SlippageModel interface {
+	Quote(price float64) (float64, float64)
+}
+
+// FixedSlippage quotes a bid and ask a fixed number of basis points either
+// side of the reference price.
+type FixedSlippage float64
+
+func (s FixedSlippage) Quote(price float64) (float64, float64) {
+	offset := price * float64(s) / 10000
+	return price - offset, price + offset
+}
+
+// Strategy is called with the exchange and each completed candle, in
+// chronological order. Orders it places are filled against the candle close,
+// and resting orders against the path of the following candles.
+type Strategy func(exchange Exchange, candle Candle) error
+
+type BacktestConfig struct {
+	Symbol   Symbol
+	Balances map[Currency]float64
+
+	// Fees charged on fills, in basis points of the notional.
+	MakerFeeBps float64
+	TakerFeeBps float64
+
+	// Slippage defaults to no slippage.
+	Slippage SlippageModel
+}
+
+type EquityPoint struct {
+	Time   time.Time
+	Equity float64
+}
+
+type BacktestReport struct {
+	Symbol        Symbol
+	Fills         []Trade
+	Equity        []EquityPoint
+	StartEquity   float64
+	EndEquity     float64
+	PnL           float64
+	RealizedPnL   float64
+	UnrealizedPnL float64
+	Fees          float64
+	// MaxDrawdown is the largest peak-to-trough fall in equity, as a
+	// fraction of the peak.
+	MaxDrawdown float64
+	// Sharpe is annualized from the per-candle or per-trade returns,
+	// assuming a zero risk-free rate.
+	Sharpe float64
+}
+
+type Backtest struct {
+	config BacktestConfig
+	market *StaticMarket
+	paper  *PaperClient
+	now    time.Time
+}
+
+func NewBacktest(config BacktestConfig) *Backtest {
+	if config.Slippage == nil {
+		config.Slippage = FixedSlippage(0)
+	}
+	config.Symbol = normalizeSymbol(config.Symbol)
+
+	b := &Backtest{config: config, market: NewStaticMarket()}
+
+	b.paper = NewPaperClient(b.market, PaperConfig{
+		MakerFeeBps: config.MakerFeeBps,
+		TakerFeeBps: config.TakerFeeBps,
+		Balances:    config.Balances,
+	})
+	b.paper.Now = func() time.Time { return b.now }
+
+	return b
+}
+
+func (b *Backtest) Exchange() Exchange {
+	return b.paper
+}
+
+func (b *Backtest) setPrice(price float64) error {
+	bid, ask := b.config.Slippage.Quote(price)
+
+	b.market.SetTicker(b.config.Symbol, Ticker{
+		Bid:  FormatAmount(bid),
+		Ask:  FormatAmount(ask),
+		Last: FormatAmount(price),
+	})
+
+	return b.paper.Sync()
+}
+
+func (b *Backtest) equity(price float64) float64 {
+	base, quote := b.config.Symbol.Split()
+
+	b.paper.mutex.Lock()
+	defer b.paper.mutex.Unlock()
+
+	return b.paper.balance(quote).amount + b.paper.balance(base).amount*price
+}
+
+// Run replays the candles through the strategy. Within each candle the price
+// is assumed to move open, low, high, close for up candles and open, high,
+// low, close for down candles.
+func (b *Backtest) Run(candles []Candle, strategy Strategy) (BacktestReport, error) {
+	report := BacktestReport{Symbol: b.config.Symbol}
+
+	base, _ := b.config.Symbol.Split()
+	if base == "" {
+		return report, errors.New("geminix: backtest symbol not recognised")
+	}
+	if len(candles) == 0 {
+		return report, errors.New("geminix: no candles to backtest")
+	}
+
+	candles = append([]Candle(nil), candles...)
+	sort.Slice(candles, func(i, j int) bool { return candles[i].Timestamp < candles[j].Timestamp })
+
+	report.StartEquity = b.equity(candles[0].Open)
+
+	for _, candle := range candles {
+		path := []float64{candle.Open, candle.Low, candle.High, candle.Close}
+		if candle.Close < candle.Open {
+			path = []float64{candle.Open, candle.High, candle.Low, candle.Close}
+		}
+
+		candle := candle
+		err := b.step(&report, candle.Time(), path, func() error { return strategy(b.paper, candle) })
+		if err != nil {
+			return report, err
+		}
+	}
+
+	return report, b.finish(&report, candles[len(candles)-1].Close, periodsPerYear(candles))
+}
+
+// TradeStrategy is called with the exchange and each trade, in chronological
+// order. Orders it places are filled against the trade price, and resting
+// orders against the prices of the following trades.
+type TradeStrategy func(exchange Exchange, trade Trade) error
+
+// RunTrades replays trades, such as those returned by Client.Trades, through
+// the strategy. The price moves to each trade's price in turn, so resting
+// orders fill in the order the market actually traded rather than along an
+// assumed path within a candle. Broken trades are skipped. Equity is
+// recorded after every trade.
+func (b *Backtest) RunTrades(trades []Trade, strategy TradeStrategy) (BacktestReport, error) {
+	report := BacktestReport{Symbol: b.config.Symbol}
+
+	base, _ := b.config.Symbol.Split()
+	if base == "" {
+		return report, errors.New("geminix: backtest symbol not recognised")
+	}
+
+	var replay []Trade
+	for _, trade := range trades {
+		if trade.Break == "" {
+			replay = append(replay, trade)
+		}
+	}
+	if len(replay) == 0 {
+		return report, errors.New("geminix: no trades to backtest")
+	}
+
+	sort.SliceStable(replay, func(i, j int) bool {
+		if replay[i].Timestampms != replay[j].Timestampms {
+			return replay[i].Timestampms < replay[j].Timestampms
+		}
+		return replay[i].Tid < replay[j].Tid
+	})
+
+	var price float64
+	for i, trade := range replay {
+		var err error
+		price, err = ParseAmount(trade.Price)
+		if err != nil || price <= 0 {
+			return report, fmt.Errorf("geminix: invalid price %q for trade %d", trade.Price, trade.Tid)
+		}
+
+		if i == 0 {
+			report.StartEquity = b.equity(price)
+		}
+
+		at := time.Unix(0, int64(trade.Timestampms)*int64(time.Millisecond))
+		trade := trade
+		err = b.step(&report, at, []float64{price}, func() error { return strategy(b.paper, trade) })
+		if err != nil {
+			return report, err
+		}
+	}
+
+	return report, b.finish(&report, price, tradesPerYear(replay))
+}
+
+// step moves the price along path, calls the strategy at the last price,
+// and records the equity.
+func (b *Backtest) step(report *BacktestReport, at time.Time, path []float64, call func() error) error {
+	b.now = at
+
+	for _, price := range path {
+		err := b.setPrice(price)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := call()
+	if err != nil {
+		return err
+	}
+
+	err = b.paper.Sync()
+	if err != nil {
+		return err
+	}
+
+	report.Equity = append(report.Equity, EquityPoint{Time: b.now, Equity: b.equity(path[len(path)-1])})
+	return nil
+}
+
+// finish fills in the report from the equity curve and the fills, valuing
+// what is left at the last price.
+func (b *Backtest) finish(report *BacktestReport, last float64, periodsPerYear float64) error {
+	base, quote := b.config.Symbol.Split()
+
+	report.EndEquity = report.Equity[len(report.Equity)-1].Equity
+	report.PnL = report.EndEquity - report.StartEquity
+
+	b.paper.mutex.Lock()
+	report.Fills = append([]Trade(nil), b.paper.trades...)
+	b.paper.mutex.Unlock()
+
+	ledger := NewLedger(FIFO, quote, nil)
+	for _, fill := range report.Fills {
+		err := ledger.AddTrade(fill)
+		if err != nil {
+			return err
+		}
+	}
+
+	pnl, err := ledger.Report(StaticValuer{base: last}, b.now)
+	if err != nil {
+		return err
+	}
+
+	report.RealizedPnL = pnl.RealizedPnL
+	report.UnrealizedPnL = pnl.UnrealizedPnL
+	report.Fees = pnl.Fees
+	report.MaxDrawdown = maxDrawdown(report.Equity)
+	report.Sharpe = sharpe(report.Equity, periodsPerYear)
+
+	return nil
+}
+
+func maxDrawdown(equity []EquityPoint) float64 {
+	var peak, drawdown float64
+	for _, point := range equity {
+		if point.Equity > peak {
+			peak = point.Equity
+		}
+		if peak > 0 {
+			if d := (peak - point.Equity) / peak; d > drawdown {
+				drawdown = d
+			}
+		}
+	}
+	return drawdown
+}
+
+func sharpe(equity []EquityPoint, periodsPerYear float64) float64 {
+	var returns []float64
+	for i := 1; i < len(equity); i++ {
+		if equity[i-1].Equity != 0 {
+			returns = append(returns, equity[i].Equity/equity[i-1].Equity-1)
+		}
+	}
+
+	if len(returns) < 2 {
+		return 0
+	}
+
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	var variance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(len(returns) - 1)
+
+	if variance == 0 {
+		return 0
+	}
+
+	return mean / math.Sqrt(variance) * math.Sqrt(periodsPerYear)
+}
+
+// periodsPerYear infers the candle interval from the smallest gap between
+// consecutive candles.
+func periodsPerYear(candles []Candle) float64 {
+	var interval uint64
+	for i := 1; i < len(candles); i++ {
+		if gap := candles[i].Timestamp - candles[i-1].Timestamp; gap > 0 && (interval == 0 || gap < interval) {
+			interval = gap
+		}
+	}
+
+	if interval == 0 {
+		return 0
+	}
+
+	return float64(365*24*time.Hour/time.Millisecond) / float64(interval)
+}
+
+// tradesPerYear infers how often trades happen from the average gap between
+// the first and last trade, since trades are not evenly spaced.
+func tradesPerYear(trades []Trade) float64 {
+	if len(trades) < 2 {
+		return 0
+	}
+
+	span := trades[len(trades)-1].Timestampms - trades[0].Timestampms
+	if span == 0 {
+		return 0
+	}
+
+	interval := float64(span) / float64(len(trades)-1)
+	return float64(365*24*time.Hour/time.Millisecond) / interval
+}
@@ -0,0 +1,270 @@
+package geminix
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var CandleColumns = []string{"timestamp", "open", "high", "low", "close", "volume"}
+
+// CandleStore persists candle series as one CSV file per symbol and time
+// frame in a directory.
+type CandleStore struct {
+	dir string
+}
+
+func NewCandleStore(dir string) *CandleStore {
+	return &CandleStore{dir: dir}
+}
+
+func (s *CandleStore) Path(symbol Symbol, timeFrame TimeFrame) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s_%s.csv", strings.ToUpper(string(symbol)), timeFrame))
+}
+
+// Load returns the stored candles, oldest first. A missing file is an empty
+// series, not an error.
+func (s *CandleStore) Load(symbol Symbol, timeFrame TimeFrame) ([]Candle, error) {
+	file, err := os.Open(s.Path(symbol, timeFrame))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var candles []Candle
+	for i, record := range records {
+		if i == 0 || len(record) != len(CandleColumns) {
+			continue
+		}
+
+		var values [6]float64
+		for j, field := range record {
+			values[j], err = strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("geminix: %s line %d: %v", s.Path(symbol, timeFrame), i+1, err)
+			}
+		}
+
+		candles = append(candles, Candle{
+			Timestamp: uint64(values[0]),
+			Open:      values[1],
+			High:      values[2],
+			Low:       values[3],
+			Close:     values[4],
+			Volume:    values[5],
+		})
+	}
+
+	return candles, nil
+}
+
+// Save replaces the stored series. The file is written to a temporary path
+// and renamed so readers never see a partial file.
+func (s *CandleStore) Save(symbol Symbol, timeFrame TimeFrame, candles []Candle) error {
+	err := os.MkdirAll(s.dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := ioutil.TempFile(s.dir, ".candles-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	writer := csv.NewWriter(file)
+	writer.Write(CandleColumns)
+	for _, candle := range candles {
+		writer.Write([]string{
+			strconv.FormatUint(candle.Timestamp, 10),
+			FormatAmount(candle.Open),
+			FormatAmount(candle.High),
+			FormatAmount(candle.Low),
+			FormatAmount(candle.Close),
+			FormatAmount(candle.Volume),
+		})
+	}
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), s.Path(symbol, timeFrame))
+}
+
+// Merge adds candles to the stored series and returns the number of new
+// timestamps and the merged series.
+func (s *CandleStore) Merge(symbol Symbol, timeFrame TimeFrame, candles []Candle) (int, []Candle, error) {
+	existing, err := s.Load(symbol, timeFrame)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	merged := MergeCandles(existing, candles)
+
+	err = s.Save(symbol, timeFrame, merged)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return len(merged) - len(existing), merged, nil
+}
+
+// MergeCandles deduplicates two series by timestamp and returns them oldest
+// first. Candles in fresh replace existing ones with the same timestamp, since
+// the most recent candle of an earlier fetch may not have been complete.
+func MergeCandles(existing []Candle, fresh []Candle) []Candle {
+	byTimestamp := map[uint64]Candle{}
+	for _, candle := range existing {
+		byTimestamp[candle.Timestamp] = candle
+	}
+	for _, candle := range fresh {
+		byTimestamp[candle.Timestamp] = candle
+	}
+
+	merged := make([]Candle, 0, len(byTimestamp))
+	for _, candle := range byTimestamp {
+		merged = append(merged, candle)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Timestamp < merged[j].Timestamp })
+
+	return merged
+}
+
+type Gap struct {
+	From time.Time
+	To   time.Time
+}
+
+// Gaps returns the periods missing from a series sorted oldest first.
+func Gaps(candles []Candle, timeFrame TimeFrame) []Gap {
+	step := uint64(timeFrame.Duration() / time.Millisecond)
+	if step == 0 {
+		return nil
+	}
+
+	var gaps []Gap
+	for i := 1; i < len(candles); i++ {
+		if candles[i].Timestamp-candles[i-1].Timestamp > step {
+			gaps = append(gaps, Gap{
+				From: time.Unix(0, int64(candles[i-1].Timestamp+step)*int64(time.Millisecond)),
+				To:   candles[i].Time(),
+			})
+		}
+	}
+
+	return gaps
+}
+
+type CandleDownload struct {
+	Symbol    Symbol
+	TimeFrame TimeFrame
+	Fetched   int
+	Added     int
+	Total     int
+	Gaps      []Gap
+	Err       error
+}
+
+// CandleDownloader keeps a local store up to date. The candles endpoint only
+// serves a recent window for each time frame, so a series stays gap-free as
+// long as Download runs at least once per window. The endpoint takes no time
+// range, so a gap left by a missed window cannot be backfilled from it; gaps
+// are reported in each CandleDownload so that they can be filled from
+// elsewhere.
+type CandleDownloader struct {
+	client     *Client
+	store      *CandleStore
+	symbols    []Symbol
+	timeFrames []TimeFrame
+}
+
+func NewCandleDownloader(client *Client, store *CandleStore, symbols []Symbol, timeFrames []TimeFrame) *CandleDownloader {
+	return &CandleDownloader{client: client, store: store, symbols: symbols, timeFrames: timeFrames}
+}
+
+// Download fetches and merges every symbol and time frame once. Failures are
+// reported per series rather than stopping the run. If ctx is done, the
+// series not yet fetched are reported with its error.
+func (d *CandleDownloader) Download(ctx context.Context) []CandleDownload {
+	var downloads []CandleDownload
+
+	client := d.client.WithContext(ctx)
+	for _, symbol := range d.symbols {
+		for _, timeFrame := range d.timeFrames {
+			download := CandleDownload{Symbol: symbol, TimeFrame: timeFrame}
+
+			if err := ctx.Err(); err != nil {
+				download.Err = err
+				downloads = append(downloads, download)
+				continue
+			}
+
+			candles, err := client.Candles(symbol, timeFrame)
+			if err != nil {
+				download.Err = err
+				downloads = append(downloads, download)
+				continue
+			}
+
+			added, merged, err := d.store.Merge(symbol, timeFrame, candles)
+			download.Fetched = len(candles)
+			download.Added = added
+			download.Total = len(merged)
+			download.Gaps = Gaps(merged, timeFrame)
+			download.Err = err
+
+			downloads = append(downloads, download)
+		}
+	}
+
+	return downloads
+}
+
+// Run downloads immediately and then at every interval until ctx is done,
+// passing each pass's results to report if it is not nil. A pass that is
+// under way when ctx is done stops early and is not reported.
+func (d *CandleDownloader) Run(ctx context.Context, interval time.Duration, report func([]CandleDownload)) error {
+	if interval <= 0 {
+		return fmt.Errorf("geminix: invalid download interval %v", interval)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		downloads := d.Download(ctx)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if report != nil {
+			report(downloads)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
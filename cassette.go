@@ -0,0 +1,212 @@
+package geminix
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+type CassetteMode int
+
+const (
+	Recording CassetteMode = iota
+	Replaying
+)
+
+// Interaction is a recorded request/response pair. The API key and signature
+// headers are never stored, and the payload is kept decoded without its
+// nonce so that replayed requests match regardless of when they are made.
+type Interaction struct {
+	Method  string                 `json:"method"`
+	Url     string                 `json:"url"`
+	Payload map[string]interface{} `json:"payload,omitempty"`
+	Status  int                    `json:"status"`
+	Body    string                 `json:"body"`
+}
+
+// Cassette is an http.RoundTripper that either records interactions to a
+// file or replays them from one.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+
+	path      string
+	mode      CassetteMode
+	transport http.RoundTripper
+	mutex     sync.Mutex
+	used      []bool
+}
+
+// NewRecorder returns a cassette that forwards requests to transport and
+// records them. If transport is nil http.DefaultTransport is used.
+func NewRecorder(path string, transport http.RoundTripper) *Cassette {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &Cassette{path: path, mode: Recording, transport: transport}
+}
+
+// LoadCassette returns a cassette that replays the interactions recorded in
+// path without touching the network.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cassette := &Cassette{path: path, mode: Replaying}
+
+	err = json.Unmarshal(data, cassette)
+	if err != nil {
+		return nil, err
+	}
+
+	cassette.used = make([]bool, len(cassette.Interactions))
+
+	return cassette, nil
+}
+
+func (c *Client) Record(path string) *Cassette {
+	cassette := NewRecorder(path, c.httpClient.Transport)
+	c.SetTransport(cassette)
+	return cassette
+}
+
+func (c *Client) Replay(path string) (*Cassette, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.SetTransport(cassette)
+	return cassette, nil
+}
+
+func (c *Cassette) Mode() CassetteMode {
+	return c.mode
+}
+
+// Save writes the recorded interactions to the cassette file.
+func (c *Cassette) Save() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(c.path, data, 0600)
+}
+
+func decodePayload(req *http.Request) (map[string]interface{}, error) {
+	header := req.Header.Get("X-GEMINI-PAYLOAD")
+	if header == "" {
+		return nil, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload map[string]interface{}
+	err = json.Unmarshal(decoded, &payload)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(payload, "nonce")
+
+	return payload, nil
+}
+
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	payload, err := decodePayload(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.mode == Replaying {
+		return c.replay(req, payload)
+	}
+
+	resp, err := c.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	c.Interactions = append(c.Interactions, Interaction{
+		Method:  req.Method,
+		Url:     req.URL.RequestURI(),
+		Payload: payload,
+		Status:  resp.StatusCode,
+		Body:    string(body),
+	})
+	c.mutex.Unlock()
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return resp, nil
+}
+
+// replay returns the first unused interaction, in recording order, that
+// matches the request method, URL and payload.
+func (c *Cassette) replay(req *http.Request, payload map[string]interface{}) (*http.Response, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	url := req.URL.RequestURI()
+
+	for i, interaction := range c.Interactions {
+		if c.used[i] || interaction.Method != req.Method || interaction.Url != url {
+			continue
+		}
+		if !reflect.DeepEqual(normalizePayload(interaction.Payload), normalizePayload(payload)) {
+			continue
+		}
+
+		c.used[i] = true
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+			StatusCode:    interaction.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": []string{"application/json"}},
+			Body:          ioutil.NopCloser(bytes.NewReader([]byte(interaction.Body))),
+			ContentLength: int64(len(interaction.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("geminix: no recorded interaction for %s %s", req.Method, url)
+}
+
+// normalizePayload round-trips a payload through JSON so that payloads built
+// in memory compare equal to ones loaded from a cassette file.
+func normalizePayload(payload map[string]interface{}) interface{} {
+	if len(payload) == 0 {
+		return nil
+	}
+
+	data, _ := json.Marshal(payload)
+
+	var normalized interface{}
+	json.Unmarshal(data, &normalized)
+
+	return normalized
+}
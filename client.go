@@ -2,10 +2,8 @@ package geminix
 
 import (
 	"bytes"
-	"crypto/hmac"
-	"crypto/sha512"
+	"context"
 	"encoding/base64"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -14,9 +12,14 @@ import (
 )
 
 type Client struct {
-	url    string
-	key    string
-	secret string
+	url        string
+	key        string
+	signer     Signer
+	httpClient *http.Client
+
+	// ctx and interceptors are shared with clients made by WithContext.
+	ctx          context.Context
+	interceptors *interceptors
 }
 
 func NewClient(key string, secret string, sandbox bool) *Client {
@@ -27,7 +30,39 @@ func NewClient(key string, secret string, sandbox bool) *Client {
 		url = BaseUrl
 	}
 
-	return &Client{url: url, key: key, secret: secret}
+	return NewClientWithUrl(url, key, secret)
+}
+
+func NewClientWithUrl(url string, key string, secret string) *Client {
+	return NewClientWithSigner(url, key, NewHMACSigner(secret))
+}
+
+// NewClientWithSigner creates a client that never sees the API secret, such
+// as one backed by a RemoteSigner.
+func NewClientWithSigner(url string, key string, signer Signer) *Client {
+	return &Client{url: url, key: key, signer: signer, httpClient: &http.Client{}, ctx: context.Background(), interceptors: &interceptors{}}
+}
+
+// String identifies the client without exposing its secret.
+func (c *Client) String() string {
+	return fmt.Sprintf("Client{url: %s, key: %s}", c.url, c.key)
+}
+
+func (c *Client) GoString() string {
+	return c.String()
+}
+
+// WithContext returns a client that sends its requests with ctx, so that
+// they can be cancelled and traced from the caller. It shares its transport
+// and interceptors with c.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	scoped := *c
+	scoped.ctx = ctx
+	return &scoped
+}
+
+func (c *Client) SetTransport(transport http.RoundTripper) {
+	c.httpClient = &http.Client{Transport: transport}
 }
 
 func (c *Client) BuildHeader(req *map[string]interface{}) (http.Header, error) {
@@ -39,10 +74,10 @@ func (c *Client) BuildHeader(req *map[string]interface{}) (http.Header, error) {
 
 	payload := base64.StdEncoding.EncodeToString([]byte(reqStr))
 
-	mac := hmac.New(sha512.New384, []byte(c.secret))
-	mac.Write([]byte(payload))
-
-	signature := hex.EncodeToString(mac.Sum(nil))
+	signature, err := c.signer.Sign(payload)
+	if err != nil {
+		return nil, err
+	}
 
 	header := http.Header{}
 	header.Set("X-GEMINI-APIKEY", c.key)
@@ -67,21 +102,42 @@ func (e *ApiError) Error() string {
 }
 
 func (c *Client) Request(verb string, uri string, params map[string]interface{}) ([]byte, error) {
-	url := c.url + uri
+	req := &RequestInfo{Context: c.ctx, Verb: verb, Uri: uri, Params: copyParams(params)}
+
+	handler := c.interceptors.chain(c.send)
+
+	res, err := handler(req)
+	if err != nil {
+		return nil, err
+	}
 
-	req, err := http.NewRequest(verb, url, bytes.NewBuffer([]byte{}))
+	return res.Body, nil
+}
+
+// send is the innermost Handler, which performs the HTTP request.
+func (c *Client) send(info *RequestInfo) (*ResponseInfo, error) {
+	url := c.url + info.Uri
+	params := info.Params
+
+	req, err := http.NewRequestWithContext(info.Context, info.Verb, url, bytes.NewBuffer([]byte{}))
 	if err != nil {
 		return nil, err
 	}
 
 	if params != nil {
-		if verb == "GET" {
+		if info.Verb == "GET" {
 			q := req.URL.Query()
 			for key, val := range params {
 				q.Add(key, val.(string))
 			}
 			req.URL.RawQuery = q.Encode()
 		} else {
+			// Interceptors may have delayed the request, so the nonce is
+			// taken again to keep nonces increasing in send order.
+			if _, ok := params["nonce"]; ok {
+				params["nonce"] = Nonce()
+			}
+
 			req.Header, err = c.BuildHeader(&params)
 			if err != nil {
 				return nil, err
@@ -89,8 +145,9 @@ func (c *Client) Request(verb string, uri string, params map[string]interface{})
 		}
 	}
 
-	httpClient := &http.Client{}
-	resp, err := httpClient.Do(req)
+	start := time.Now()
+
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -101,13 +158,15 @@ func (c *Client) Request(verb string, uri string, params map[string]interface{})
 		return nil, err
 	}
 
-	var res Response
-	json.Unmarshal(body, &res)
-	if res.Result == "error" {
-		return nil, &res.ApiError
+	res := &ResponseInfo{Status: resp.StatusCode, Body: body, Latency: time.Since(start)}
+
+	var r Response
+	json.Unmarshal(body, &r)
+	if r.Result == "error" {
+		return res, &r.ApiError
 	}
 
-	return body, nil
+	return res, nil
 }
 
 func (c *Client) PublicRequest(uri string) ([]byte, error) {
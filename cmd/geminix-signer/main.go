@@ -0,0 +1,97 @@
+// Command geminix-signer holds API secrets and signs requests for clients
+// connecting over a unix socket, so trading hosts never load the secret.
+//
+// Each -profile names a section of the credentials file whose key will be
+// served. Clients connect with geminix.DialSigner("unix", socket, key).
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	geminix "github.com/Haakam21/gemini-exchange-go"
+)
+
+func main() {
+	socket := flag.String("socket", defaultSocketPath(), "unix socket to listen on")
+	credentialsPath := flag.String("credentials", geminix.DefaultCredentialsPath(), "credentials file path")
+	profiles := flag.String("profile", geminix.DefaultProfile, "comma separated credentials file profiles to serve")
+	allowWithdrawals := flag.Bool("allow-withdrawals", false, "sign withdrawal and transfer requests")
+	flag.Parse()
+
+	server := geminix.NewSignerServer()
+
+	for _, profile := range strings.Split(*profiles, ",") {
+		provider := geminix.FileProvider{Path: *credentialsPath, Profile: strings.TrimSpace(profile)}
+
+		credentials, err := provider.Credentials(context.Background())
+		if err != nil {
+			fatal(fmt.Errorf("profile %s: %v", profile, err))
+		}
+
+		server.AddKey(credentials.Key, geminix.NewHMACSigner(credentials.Secret))
+		log.Printf("serving %s", credentials)
+	}
+
+	if !*allowWithdrawals {
+		server.Authorize = denyWithdrawals
+	}
+
+	err := os.MkdirAll(filepath.Dir(*socket), 0700)
+	if err != nil {
+		fatal(err)
+	}
+
+	listener, err := geminix.ListenUnix(*socket)
+	if err != nil {
+		fatal(err)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		listener.Close()
+	}()
+
+	log.Printf("listening on %s", *socket)
+
+	err = server.Serve(listener)
+	os.Remove(*socket)
+	if err != nil && !errors.Is(err, net.ErrClosed) {
+		fatal(err)
+	}
+}
+
+func denyWithdrawals(key string, request map[string]interface{}) error {
+	uri, _ := request["request"].(string)
+
+	if strings.HasPrefix(uri, "/v1/withdraw/") || strings.HasPrefix(uri, "/v1/account/transfer/") {
+		log.Printf("refused %s for %s", uri, key)
+		return fmt.Errorf("signer refuses %s", uri)
+	}
+
+	return nil
+}
+
+func defaultSocketPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "geminix-signer.sock"
+	}
+	return filepath.Join(dir, "geminix", "signer.sock")
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "geminix-signer:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	geminix "github.com/Haakam21/gemini-exchange-go"
+)
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ContinueOnError)
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+func parseOrderId(s string) (uint, error) {
+	id, err := strconv.ParseUint(s, 10, 64)
+	return uint(id), err
+}
+
+func orderRows(orders []geminix.Order) [][]string {
+	var rows [][]string
+	for _, o := range orders {
+		rows = append(rows, []string{
+			o.OrderId, o.ClientOrderId, o.Symbol, o.Side, o.Price, o.OriginalAmount,
+			o.ExecutedAmount, o.RemainingAmount, strconv.FormatBool(o.IsLive), strconv.FormatBool(o.IsCancelled),
+		})
+	}
+	return rows
+}
+
+var orderHeader = []string{"ORDER ID", "CLIENT ID", "SYMBOL", "SIDE", "PRICE", "AMOUNT", "EXECUTED", "REMAINING", "LIVE", "CANCELLED"}
+
+func (c *cli) ticker(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	ticker, err := c.client.Ticker(geminix.Symbol(args[0]))
+	if err != nil {
+		return err
+	}
+
+	return c.print(ticker, []string{"BID", "ASK", "LAST"}, [][]string{{ticker.Bid, ticker.Ask, ticker.Last}})
+}
+
+func (c *cli) book(args []string) error {
+	flags := newFlagSet("book")
+	limit := flags.Uint("limit", 10, "levels per side")
+	if len(args) == 0 {
+		return errUsage
+	}
+	if err := flags.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+
+	orderBook, err := c.client.OrderBook(geminix.Symbol(args[0]), limit, limit)
+	if err != nil {
+		return err
+	}
+
+	var rows [][]string
+	for i := len(orderBook.Asks) - 1; i >= 0; i-- {
+		rows = append(rows, []string{"ask", orderBook.Asks[i].Price, orderBook.Asks[i].Amount})
+	}
+	for _, bid := range orderBook.Bids {
+		rows = append(rows, []string{"bid", bid.Price, bid.Amount})
+	}
+
+	return c.print(orderBook, []string{"SIDE", "PRICE", "AMOUNT"}, rows)
+}
+
+func (c *cli) balances(args []string) error {
+	balances, err := c.client.Balances(c.account)
+	if err != nil {
+		return err
+	}
+
+	var rows [][]string
+	for _, b := range balances {
+		rows = append(rows, []string{string(b.Currency), b.Amount, b.Available, b.AvailableForWithdrawal})
+	}
+
+	return c.print(balances, []string{"CURRENCY", "AMOUNT", "AVAILABLE", "WITHDRAWABLE"}, rows)
+}
+
+func (c *cli) orders(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "list":
+		orders, err := c.client.ActiveOrders(c.account)
+		if err != nil {
+			return err
+		}
+		return c.print(orders, orderHeader, orderRows(orders))
+
+	case "new":
+		flags := newFlagSet("orders new")
+		symbol := flags.String("symbol", "", "symbol")
+		side := flags.String("side", "", "buy or sell")
+		amount := flags.String("amount", "", "amount")
+		price := flags.String("price", "", "limit price")
+		options := flags.String("options", "", "comma separated order options")
+		clientOrderId := flags.Uint("client-order-id", 0, "client order id")
+		if err := flags.Parse(args[1:]); err != nil {
+			return errUsage
+		}
+		if *symbol == "" || *side == "" || *amount == "" || *price == "" {
+			return errUsage
+		}
+
+		var optionList *[]string
+		if *options != "" {
+			list := strings.Split(*options, ",")
+			optionList = &list
+		}
+
+		var clientOrderIdPtr *uint
+		if *clientOrderId != 0 {
+			clientOrderIdPtr = clientOrderId
+		}
+
+		order, err := c.client.NewOrder(clientOrderIdPtr, geminix.Symbol(*symbol), *amount, nil, *price, *side, geminix.ExchangeLimit, optionList, nil, c.account)
+		if err != nil {
+			return err
+		}
+		return c.print(order, orderHeader, orderRows([]geminix.Order{order}))
+
+	case "cancel":
+		if len(args) != 2 {
+			return errUsage
+		}
+		orderId, err := parseOrderId(args[1])
+		if err != nil {
+			return err
+		}
+
+		order, err := c.client.CancelOrder(orderId, c.account)
+		if err != nil {
+			return err
+		}
+		return c.print(order, orderHeader, orderRows([]geminix.Order{order}))
+
+	case "replace":
+		if len(args) < 2 {
+			return errUsage
+		}
+		orderId, err := parseOrderId(args[1])
+		if err != nil {
+			return err
+		}
+
+		flags := newFlagSet("orders replace")
+		price := flags.String("price", "", "new limit price")
+		amount := flags.String("amount", "", "new total amount, including what has executed")
+		clientOrderId := flags.Uint("client-order-id", 0, "client order id of the replacement, linking it to the original")
+		if err := flags.Parse(args[2:]); err != nil {
+			return errUsage
+		}
+		if *price == "" || *amount == "" || *clientOrderId == 0 {
+			return errUsage
+		}
+
+		result, err := c.client.ReplaceOrder(orderId, *price, *amount, *clientOrderId, c.account)
+		if err != nil {
+			return err
+		}
+
+		orders := []geminix.Order{result.Original}
+		if result.Replacement != nil {
+			orders = append(orders, *result.Replacement)
+		}
+		return c.print(result, orderHeader, orderRows(orders))
+
+	case "cancel-all":
+		result, err := c.client.CancelAll(c.account)
+		if err != nil {
+			return err
+		}
+
+		var rows [][]string
+		for _, id := range result.Details.CancelledOrders {
+			rows = append(rows, []string{strconv.FormatUint(id, 10), "cancelled"})
+		}
+		for _, id := range result.Details.CancelRejects {
+			rows = append(rows, []string{strconv.FormatUint(id, 10), "rejected"})
+		}
+		return c.print(result, []string{"ORDER ID", "RESULT"}, rows)
+	}
+
+	return errUsage
+}
+
+func (c *cli) candles(args []string) error {
+	if len(args) == 0 || args[0] != "download" {
+		return errUsage
+	}
+
+	flags := newFlagSet("candles download")
+	dir := flags.String("dir", defaultPath("candles"), "directory of the candle store")
+	symbols := flags.String("symbols", "", "comma separated symbols")
+	timeFrames := flags.String("time-frames", string(geminix.OneDay), "comma separated time frames")
+	every := flags.Duration("every", 0, "keep downloading at this interval until interrupted")
+	if err := flags.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+	if *symbols == "" || *timeFrames == "" {
+		return errUsage
+	}
+
+	var symbolList []geminix.Symbol
+	for _, symbol := range strings.Split(*symbols, ",") {
+		symbolList = append(symbolList, geminix.Symbol(symbol))
+	}
+	var timeFrameList []geminix.TimeFrame
+	for _, timeFrame := range strings.Split(*timeFrames, ",") {
+		timeFrameList = append(timeFrameList, geminix.TimeFrame(timeFrame))
+	}
+
+	downloader := geminix.NewCandleDownloader(c.client, geminix.NewCandleStore(*dir), symbolList, timeFrameList)
+
+	if *every == 0 {
+		return c.printDownloads(downloader.Download(context.Background()))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var printErr error
+	err := downloader.Run(ctx, *every, func(downloads []geminix.CandleDownload) {
+		if err := c.printDownloads(downloads); err != nil && printErr == nil {
+			printErr = err
+		}
+	})
+	if err == context.Canceled {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	return printErr
+}
+
+// candleDownload is a CandleDownload with its error as text, for JSON.
+type candleDownload struct {
+	geminix.CandleDownload
+	Err string
+}
+
+func (c *cli) printDownloads(downloads []geminix.CandleDownload) error {
+	var results []candleDownload
+	var rows [][]string
+	for _, d := range downloads {
+		result := candleDownload{CandleDownload: d}
+		if d.Err != nil {
+			result.Err = d.Err.Error()
+		}
+		results = append(results, result)
+
+		rows = append(rows, []string{
+			string(d.Symbol), string(d.TimeFrame), strconv.Itoa(d.Fetched), strconv.Itoa(d.Added),
+			strconv.Itoa(d.Total), strconv.Itoa(len(d.Gaps)), result.Err,
+		})
+	}
+
+	return c.print(results, []string{"SYMBOL", "TIME FRAME", "FETCHED", "ADDED", "TOTAL", "GAPS", "ERROR"}, rows)
+}
+
+func (c *cli) trades(args []string) error {
+	flags := newFlagSet("trades")
+	limit := flags.Uint("limit", 50, "number of trades, ignored with -since")
+	since := flags.String("since", "", "fetch the full history since this time (RFC 3339 or YYYY-MM-DD)")
+	if len(args) == 0 {
+		return errUsage
+	}
+	if err := flags.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+
+	symbol := geminix.Symbol(args[0])
+
+	var trades []geminix.Trade
+	var err error
+	if *since != "" {
+		start, parseErr := parseTime(*since)
+		if parseErr != nil {
+			return parseErr
+		}
+		trades, err = c.client.TradeHistory(context.Background(), symbol, start, c.account).All()
+	} else {
+		trades, err = c.client.PastTrades(symbol, limit, nil, c.account)
+	}
+	if err != nil {
+		return err
+	}
+
+	var rows [][]string
+	for _, t := range trades {
+		rows = append(rows, []string{
+			time.Unix(0, int64(t.Timestampms)*int64(time.Millisecond)).UTC().Format(time.RFC3339),
+			strconv.FormatUint(uint64(t.Tid), 10), t.OrderId, t.Type, t.Price, t.Amount, t.FeeAmount + " " + t.FeeCurrency,
+		})
+	}
+
+	return c.print(trades, []string{"TIME", "TID", "ORDER ID", "TYPE", "PRICE", "AMOUNT", "FEE"}, rows)
+}
+
+func (c *cli) transfers(args []string) error {
+	flags := newFlagSet("transfers")
+	limit := flags.Uint("limit", 10, "number of transfers, ignored with -since")
+	since := flags.String("since", "", "fetch the full history since this time (RFC 3339 or YYYY-MM-DD)")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	var transfers []geminix.Transfer
+	var err error
+	if *since != "" {
+		start, parseErr := parseTime(*since)
+		if parseErr != nil {
+			return parseErr
+		}
+		transfers, err = c.client.TransferHistory(context.Background(), start, c.account, nil).All()
+	} else {
+		transfers, err = c.client.Transfers(nil, limit, c.account, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	var rows [][]string
+	for _, t := range transfers {
+		rows = append(rows, []string{
+			time.Unix(0, int64(t.Timestampms)*int64(time.Millisecond)).UTC().Format(time.RFC3339),
+			strconv.FormatUint(uint64(t.EID), 10), t.Type, t.Status, string(t.Currency), t.Amount, t.Destination,
+		})
+	}
+
+	return c.print(transfers, []string{"TIME", "EID", "TYPE", "STATUS", "CURRENCY", "AMOUNT", "DESTINATION"}, rows)
+}
+
+func (c *cli) addresses(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	addresses, err := c.client.DepositAddresses(geminix.Network(args[0]), c.account)
+	if err != nil {
+		return err
+	}
+
+	var rows [][]string
+	for _, a := range addresses {
+		rows = append(rows, []string{a.Address, a.Label, strconv.FormatUint(a.Timestamp, 10)})
+	}
+
+	return c.print(addresses, []string{"ADDRESS", "LABEL", "TIMESTAMP"}, rows)
+}
+
+func (c *cli) accounts(args []string) error {
+	accounts, err := c.client.Accounts()
+	if err != nil {
+		return err
+	}
+
+	var rows [][]string
+	for _, a := range accounts {
+		rows = append(rows, []string{a.Account, a.Name, a.Type, a.CounterpartyId})
+	}
+
+	return c.print(accounts, []string{"ACCOUNT", "NAME", "TYPE", "COUNTERPARTY ID"}, rows)
+}
@@ -0,0 +1,195 @@
+// Command geminix exposes the geminix library on the command line.
+//
+// Credentials are read from GEMINI_API_KEY and GEMINI_API_SECRET, or from a
+// profile in the credentials file (~/.geminix/credentials by default). With
+// -signer, only the key is needed; the secret stays with the signer.
+//
+// Withdrawals go through a geminix.WithdrawalGuard. Its policy is read from
+// a JSON file (~/.geminix/withdrawal-policy.json by default) and without one
+// nothing can be withdrawn. Pending proposals are kept in another file so
+// that a withdrawal can be proposed and approved by separate invocations.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	geminix "github.com/Haakam21/gemini-exchange-go"
+)
+
+const usage = `usage: geminix [flags] <command> [args]
+
+commands:
+  ticker <symbol>
+  book <symbol> [-limit n]
+  balances
+  orders list
+  orders new -symbol s -side buy|sell -amount a -price p [-options o1,o2] [-client-order-id n]
+  orders cancel <order id>
+  orders replace <order id> -price p -amount a -client-order-id n
+  orders cancel-all
+  trades <symbol> [-limit n] [-since time]
+  candles download -symbols s1,s2 [-time-frames t1,t2] [-dir d] [-every duration]
+  transfers [-limit n] [-since time]
+  withdraw <currency> <address> <amount>
+  withdraw propose <currency> <address> <amount>
+  withdraw approve <proposal id>
+  withdraw reject <proposal id>
+  withdraw proposals
+  addresses <network>
+  accounts
+
+flags:
+`
+
+type cli struct {
+	client  *geminix.Client
+	account *string
+	json    bool
+
+	policyPath    string
+	proposalsPath string
+}
+
+func defaultProfile() string {
+	if profile := os.Getenv("GEMINI_PROFILE"); profile != "" {
+		return profile
+	}
+	return geminix.DefaultProfile
+}
+
+// defaultPath returns a path next to the default credentials file.
+func defaultPath(name string) string {
+	credentials := geminix.DefaultCredentialsPath()
+	if credentials == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(credentials), name)
+}
+
+func main() {
+	flags := flag.NewFlagSet("geminix", flag.ExitOnError)
+	sandbox := flags.Bool("sandbox", false, "use the sandbox exchange")
+	account := flags.String("account", "", "account to act on, for master keys")
+	jsonOutput := flags.Bool("json", false, "print JSON instead of tables")
+	credentialsPath := flags.String("credentials", geminix.DefaultCredentialsPath(), "credentials file path")
+	profile := flags.String("profile", defaultProfile(), "credentials file profile")
+	url := flags.String("url", "", "override the API base URL")
+	signer := flags.String("signer", "", "sign requests through the geminix-signer socket at this path")
+	policyPath := flags.String("policy", defaultPath("withdrawal-policy.json"), "withdrawal policy file path")
+	proposalsPath := flags.String("proposals", defaultPath("withdrawal-proposals.json"), "pending withdrawal proposals file path")
+	flags.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[1:])
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	// With a signer, the secret stays with the signer and only the key is
+	// needed here.
+	keyOnly := *signer != ""
+	provider := geminix.ChainProvider{
+		geminix.EnvProvider{KeyOnly: keyOnly},
+		geminix.FileProvider{Path: *credentialsPath, Profile: *profile, KeyOnly: keyOnly},
+	}
+	credentials, err := provider.Credentials(context.Background())
+	if err != nil && err != geminix.ErrNoCredentials {
+		fatal(err)
+	}
+	if *sandbox {
+		credentials.Sandbox = true
+	}
+
+	baseUrl := geminix.BaseUrl
+	if credentials.Sandbox {
+		baseUrl = geminix.SandboxBaseUrl
+	}
+	if *url != "" {
+		baseUrl = *url
+	}
+
+	c := &cli{json: *jsonOutput, policyPath: *policyPath, proposalsPath: *proposalsPath}
+	if *signer != "" {
+		remote, err := geminix.DialSigner("unix", *signer, credentials.Key)
+		if err != nil {
+			fatal(err)
+		}
+		defer remote.Close()
+
+		c.client = geminix.NewClientWithSigner(baseUrl, credentials.Key, remote)
+	} else {
+		c.client = geminix.NewClientWithUrl(baseUrl, credentials.Key, credentials.Secret)
+	}
+	if *account != "" {
+		c.account = account
+	}
+
+	err = c.run(flags.Arg(0), flags.Args()[1:])
+	if err == errUsage {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+var errUsage = errors.New("usage")
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "geminix:", err)
+	os.Exit(1)
+}
+
+func (c *cli) run(command string, args []string) error {
+	switch command {
+	case "ticker":
+		return c.ticker(args)
+	case "book":
+		return c.book(args)
+	case "balances":
+		return c.balances(args)
+	case "orders":
+		return c.orders(args)
+	case "trades":
+		return c.trades(args)
+	case "candles":
+		return c.candles(args)
+	case "transfers":
+		return c.transfers(args)
+	case "withdraw":
+		return c.withdraw(args)
+	case "addresses":
+		return c.addresses(args)
+	case "accounts":
+		return c.accounts(args)
+	}
+	return errUsage
+}
+
+// print writes v as JSON, or rows as a table under header.
+func (c *cli) print(v interface{}, header []string, rows [][]string) error {
+	if c.json {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	return writer.Flush()
+}
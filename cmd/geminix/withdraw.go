@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	geminix "github.com/Haakam21/gemini-exchange-go"
+)
+
+var withdrawalHeader = []string{"ADDRESS", "AMOUNT", "TX HASH", "WITHDRAWAL ID"}
+
+var proposalHeader = []string{"ID", "CURRENCY", "ADDRESS", "AMOUNT", "PROPOSED AT"}
+
+func proposalRows(proposals []geminix.WithdrawalProposal) [][]string {
+	var rows [][]string
+	for _, p := range proposals {
+		rows = append(rows, []string{p.Id, string(p.Currency), p.Address, p.Amount, p.ProposedAt.UTC().Format(time.RFC3339)})
+	}
+	return rows
+}
+
+func (c *cli) withdraw(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	guard, err := c.withdrawalGuard()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "propose":
+		if len(args) != 4 {
+			return errUsage
+		}
+
+		proposal, err := guard.Propose(geminix.Currency(strings.ToUpper(args[1])), args[2], args[3], c.account)
+		if err != nil {
+			return err
+		}
+		if err := c.saveProposals(guard); err != nil {
+			return err
+		}
+
+		return c.print(proposal, proposalHeader, proposalRows([]geminix.WithdrawalProposal{proposal}))
+
+	case "approve":
+		if len(args) != 2 {
+			return errUsage
+		}
+
+		// Count the withdrawals of the proposal's account, not of -account.
+		account := c.account
+		for _, proposal := range guard.Proposals() {
+			if proposal.Id == args[1] {
+				account = proposal.Account
+			}
+		}
+		if err := guard.Sync(context.Background(), account); err != nil {
+			return err
+		}
+
+		withdrawal, err := guard.Approve(args[1])
+		var uncertain *geminix.UncertainWithdrawalError
+		if errors.As(err, &uncertain) {
+			// The withdrawal may have been sent, so the proposal must not be
+			// approved again.
+			if saveErr := c.saveProposals(guard); saveErr != nil {
+				return fmt.Errorf("%v; proposal %s not removed from %s: %v", err, args[1], c.proposalsPath, saveErr)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "withdrawal submitted")
+
+		printErr := c.print(withdrawal, withdrawalHeader,
+			[][]string{{withdrawal.Address, withdrawal.Amount, withdrawal.TxHash, withdrawal.WithdrawalId}})
+		if err := c.saveProposals(guard); err != nil {
+			return fmt.Errorf("withdrawal sent but proposal %s not removed from %s: %v", args[1], c.proposalsPath, err)
+		}
+		return printErr
+
+	case "reject":
+		if len(args) != 2 {
+			return errUsage
+		}
+
+		if err := guard.Reject(args[1]); err != nil {
+			return err
+		}
+		return c.saveProposals(guard)
+
+	case "proposals":
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		proposals := guard.Proposals()
+		return c.print(proposals, proposalHeader, proposalRows(proposals))
+	}
+
+	if len(args) != 3 {
+		return errUsage
+	}
+
+	if err := guard.Sync(context.Background(), c.account); err != nil {
+		return err
+	}
+
+	withdrawal, err := guard.WithdrawCrypto(geminix.Currency(strings.ToUpper(args[0])), args[1], args[2], c.account)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, "withdrawal submitted")
+
+	return c.print(withdrawal, withdrawalHeader,
+		[][]string{{withdrawal.Address, withdrawal.Amount, withdrawal.TxHash, withdrawal.WithdrawalId}})
+}
+
+// withdrawalGuard returns a guard with the policy from the policy file and
+// the proposals from the proposals file. Without a policy file, nothing can
+// be withdrawn.
+func (c *cli) withdrawalGuard() (*geminix.WithdrawalGuard, error) {
+	data, err := ioutil.ReadFile(c.policyPath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no withdrawal policy at %s", c.policyPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var policy geminix.WithdrawalPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("withdrawal policy %s: %v", c.policyPath, err)
+	}
+
+	guard := geminix.NewWithdrawalGuard(c.client, policy)
+
+	data, err = ioutil.ReadFile(c.proposalsPath)
+	if os.IsNotExist(err) {
+		return guard, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var proposals []geminix.WithdrawalProposal
+	if err := json.Unmarshal(data, &proposals); err != nil {
+		return nil, fmt.Errorf("withdrawal proposals %s: %v", c.proposalsPath, err)
+	}
+	guard.RestoreProposals(proposals)
+
+	return guard, nil
+}
+
+// saveProposals writes the guard's pending proposals to the proposals file,
+// through a temporary file so that a crash never leaves a partial file.
+func (c *cli) saveProposals(guard *geminix.WithdrawalGuard) error {
+	data, err := json.MarshalIndent(guard.Proposals(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(c.proposalsPath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	file, err := ioutil.TempFile(dir, ".proposals-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(data)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), c.proposalsPath)
+}
@@ -67,6 +67,46 @@ const (
 	HeartbeatUri = "/v1/heartbeat"
 )
 
+// Uris lists every endpoint, for mapping request paths back to the URI
+// constants with Endpoint.
+var Uris = []string{
+	SymbolsUri, SymbolDetailsUri, TickerUri, TickerV2Uri, CandlesUri, OrderBookUri, TradesUri, AuctionUri,
+	AuctionHistoryUri, RolesUri, NewOrderUri, CancelOrderUri, CancelSessionUri, CancelAllUri, OrderStatusUri,
+	ActiveOrdersUri, PastTradesUri, NotionalVolumeUri, TradeVolumeUri, NewClearingOrderUri, NewBrokerOrderUri,
+	ClearingOrderStatusUri, CancelClearingOrderUri, ConfirmClearingOrderUri, BalancesUri, NotionalBalancesUri,
+	TransfersUri, DepositAddressesUri, NewDepositAddressUri, WithdrawCryptoUri, InternalTransferUri, AddBankUri,
+	PaymentMethodsUri, RequestAddressUri, ApprovedAddressesUri, RemoveAddressUri, AccountDetailUri,
+	CreateAccountUri, AccountsUri, HeartbeatUri,
+}
+
+const (
+	OneMinute      TimeFrame = "1m"
+	FiveMinutes    TimeFrame = "5m"
+	FifteenMinutes TimeFrame = "15m"
+	ThirtyMinutes  TimeFrame = "30m"
+	OneHour        TimeFrame = "1hr"
+	SixHours       TimeFrame = "6hr"
+	OneDay         TimeFrame = "1day"
+)
+
+const (
+	Buy  = "buy"
+	Sell = "sell"
+
+	ExchangeLimit = "exchange limit"
+
+	MakerOrCancel        = "maker-or-cancel"
+	ImmediateOrCancel    = "immediate-or-cancel"
+	FillOrKill           = "fill-or-kill"
+	AuctionOnly          = "auction-only"
+	IndicationOfInterest = "indication-of-interest"
+)
+
+const (
+	MaxLimitTrades    = 500
+	MaxLimitTransfers = 50
+)
+
 const (
 	BTCUSD     Symbol = "BTCUSD"
 	ETHBTC     Symbol = "ETHBTC"
@@ -0,0 +1,250 @@
+package geminix
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var ErrNoCredentials = errors.New("geminix: no credentials found")
+
+const DefaultProfile = "default"
+
+// Credentials is an API key pair. Its String, GoString and MarshalJSON
+// methods redact the secret so that credentials can be logged safely.
+type Credentials struct {
+	Key     string
+	Secret  string
+	Sandbox bool
+}
+
+func (c Credentials) String() string {
+	return fmt.Sprintf("Credentials{Key: %s, Secret: %s, Sandbox: %t}", c.Key, redact(c.Secret), c.Sandbox)
+}
+
+func (c Credentials) GoString() string {
+	return c.String()
+}
+
+func (c Credentials) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"key":     c.Key,
+		"secret":  redact(c.Secret),
+		"sandbox": c.Sandbox,
+	})
+}
+
+func (c Credentials) Valid() bool {
+	return c.Key != "" && c.Secret != ""
+}
+
+// usable reports whether the credentials are valid, or have a key if
+// keyOnly.
+func (c Credentials) usable(keyOnly bool) bool {
+	if keyOnly {
+		return c.Key != ""
+	}
+	return c.Valid()
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "REDACTED"
+}
+
+// SecretProvider loads credentials from a source such as the environment, a
+// file or a secret manager.
+type SecretProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+type StaticProvider Credentials
+
+func (p StaticProvider) Credentials(ctx context.Context) (Credentials, error) {
+	return Credentials(p), nil
+}
+
+// EnvProvider reads <Prefix>_API_KEY, <Prefix>_API_SECRET and
+// <Prefix>_SANDBOX. The prefix defaults to GEMINI.
+type EnvProvider struct {
+	Prefix string
+
+	// KeyOnly accepts a key without a secret, for clients whose requests
+	// are signed elsewhere, such as by a RemoteSigner.
+	KeyOnly bool
+}
+
+func (p EnvProvider) Credentials(ctx context.Context) (Credentials, error) {
+	prefix := p.Prefix
+	if prefix == "" {
+		prefix = "GEMINI"
+	}
+
+	credentials := Credentials{
+		Key:    os.Getenv(prefix + "_API_KEY"),
+		Secret: os.Getenv(prefix + "_API_SECRET"),
+	}
+	if !credentials.usable(p.KeyOnly) {
+		return Credentials{}, ErrNoCredentials
+	}
+
+	if sandbox := os.Getenv(prefix + "_SANDBOX"); sandbox != "" {
+		value, err := strconv.ParseBool(sandbox)
+		if err != nil {
+			return Credentials{}, fmt.Errorf("geminix: %s_SANDBOX: %v", prefix, err)
+		}
+		credentials.Sandbox = value
+	}
+
+	return credentials, nil
+}
+
+// FileProvider reads a named profile from a credentials file in INI format:
+//
+//	[default]
+//	key = account-...
+//	secret = ...
+//	sandbox = false
+//
+// The path defaults to DefaultCredentialsPath and the profile to
+// DefaultProfile.
+type FileProvider struct {
+	Path    string
+	Profile string
+
+	// KeyOnly accepts profiles without a secret, as EnvProvider.KeyOnly.
+	KeyOnly bool
+}
+
+func DefaultCredentialsPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".geminix", "credentials")
+}
+
+func (p FileProvider) Credentials(ctx context.Context) (Credentials, error) {
+	path := p.Path
+	if path == "" {
+		path = DefaultCredentialsPath()
+	}
+
+	profile := p.Profile
+	if profile == "" {
+		profile = DefaultProfile
+	}
+
+	profiles, err := LoadProfiles(path)
+	if os.IsNotExist(err) {
+		return Credentials{}, ErrNoCredentials
+	}
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	credentials, ok := profiles[profile]
+	if !ok || !credentials.usable(p.KeyOnly) {
+		return Credentials{}, ErrNoCredentials
+	}
+
+	return credentials, nil
+}
+
+// LoadProfiles parses every profile in a credentials file.
+func LoadProfiles(path string) (map[string]Credentials, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	profiles := map[string]Credentials{}
+	profile := ""
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			profile = strings.TrimSpace(text[1 : len(text)-1])
+			profiles[profile] = profiles[profile]
+			continue
+		}
+
+		i := strings.Index(text, "=")
+		if i < 0 || profile == "" {
+			return nil, fmt.Errorf("geminix: %s:%d: expected key = value inside a [profile]", path, line)
+		}
+
+		name := strings.TrimSpace(text[:i])
+		value := strings.TrimSpace(text[i+1:])
+
+		credentials := profiles[profile]
+		switch name {
+		case "key":
+			credentials.Key = value
+		case "secret":
+			credentials.Secret = value
+		case "sandbox":
+			credentials.Sandbox, err = strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("geminix: %s:%d: %v", path, line, err)
+			}
+		default:
+			return nil, fmt.Errorf("geminix: %s:%d: unknown setting %q", path, line, name)
+		}
+		profiles[profile] = credentials
+	}
+
+	return profiles, scanner.Err()
+}
+
+// ChainProvider tries each provider in turn and returns the first
+// credentials found. Errors other than ErrNoCredentials stop the search.
+type ChainProvider []SecretProvider
+
+func (p ChainProvider) Credentials(ctx context.Context) (Credentials, error) {
+	for _, provider := range p {
+		credentials, err := provider.Credentials(ctx)
+		if err == nil {
+			return credentials, nil
+		}
+		if err != ErrNoCredentials {
+			return Credentials{}, err
+		}
+	}
+
+	return Credentials{}, ErrNoCredentials
+}
+
+// DefaultProvider looks in the environment and then in the given profile of
+// the default credentials file.
+func DefaultProvider(profile string) SecretProvider {
+	return ChainProvider{EnvProvider{}, FileProvider{Profile: profile}}
+}
+
+func NewClientFromCredentials(credentials Credentials) *Client {
+	return NewClient(credentials.Key, credentials.Secret, credentials.Sandbox)
+}
+
+func NewClientFromProvider(ctx context.Context, provider SecretProvider) (*Client, error) {
+	credentials, err := provider.Credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClientFromCredentials(credentials), nil
+}
@@ -0,0 +1,26 @@
+package geminix
+
+// MarketData is the public market data needed to simulate fills.
+type MarketData interface {
+	Ticker(symbol Symbol) (Ticker, error)
+	OrderBook(symbol Symbol, limitBids *uint, limitAsks *uint) (OrderBook, error)
+}
+
+// Exchange is the trading surface shared by Client and PaperClient, so that
+// strategies can be switched between paper and live trading.
+type Exchange interface {
+	MarketData
+
+	NewOrder(clientOrderId *uint, symbol Symbol, amount string, minAmount *string, price string, side string, Type string, options *[]string, stopPrice *string, account *string) (Order, error)
+	CancelOrder(orderId uint, account *string) (Order, error)
+	CancelAll(account *string) (CancelResult, error)
+	OrderStatus(orderId uint, clientOrderId *uint, includeTrades *bool, account *string) (Order, error)
+	ActiveOrders(account *string) ([]Order, error)
+	PastTrades(symbol Symbol, limitTrades *uint, timestamp *uint64, account *string) ([]Trade, error)
+	Balances(account *string) ([]Balance, error)
+}
+
+var (
+	_ Exchange = (*Client)(nil)
+	_ Exchange = (*PaperClient)(nil)
+)
@@ -0,0 +1,218 @@
+// Package execution works a large parent order through a geminix.Exchange
+// as a series of smaller child orders.
+//
+// Child orders are posted passively with the maker-or-cancel option, at the
+// bid when buying and the ask when selling, so that they pay maker fees and
+// never cross the spread. A Slicer cancels a child that is still resting
+// when its interval is up and carries whatever it did not fill into the
+// next one. An Iceberg shows one child at a time and places the next once
+// it is done.
+//
+//	slicer := execution.TWAP(client, execution.Parent{
+//		Symbol: "btcusd",
+//		Side:   geminix.Buy,
+//		Amount: 10,
+//	}, time.Hour, 12)
+//	report, err := slicer.Run(ctx)
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	geminix "github.com/Haakam21/gemini-exchange-go"
+)
+
+// Parent is the order to work.
+type Parent struct {
+	Symbol geminix.Symbol
+	Side   string
+	Amount float64
+
+	// LimitPrice is the worst price a child may be posted at. Zero means no
+	// limit.
+	LimitPrice float64
+
+	// AmountDecimals is the precision child amounts are rounded down to. It
+	// defaults to 8.
+	AmountDecimals int
+
+	// MinAmount is the smallest child the exchange accepts. Smaller
+	// amounts are carried into the next child.
+	MinAmount float64
+
+	Account *string
+}
+
+func (p Parent) validate() error {
+	if p.Side != geminix.Buy && p.Side != geminix.Sell {
+		return fmt.Errorf("execution: invalid side %q", p.Side)
+	}
+	if p.Amount <= 0 {
+		return fmt.Errorf("execution: invalid amount %v", p.Amount)
+	}
+	return nil
+}
+
+func (p Parent) round(amount float64) float64 {
+	decimals := p.AmountDecimals
+	if decimals == 0 {
+		decimals = 8
+	}
+	scale := math.Pow(10, float64(decimals))
+	return math.Floor(amount*scale+1e-6) / scale
+}
+
+// Report summarises the child orders of a parent order.
+type Report struct {
+	Filled    float64
+	Notional  float64
+	Remaining float64
+
+	// Children are the child orders in their final state, in the order
+	// they were placed.
+	Children []geminix.Order
+}
+
+func (r Report) AveragePrice() float64 {
+	if r.Filled == 0 {
+		return 0
+	}
+	return r.Notional / r.Filled
+}
+
+func (r *Report) finish(parent Parent) {
+	r.Remaining = parent.round(parent.Amount - r.Filled)
+	if r.Remaining < 0 {
+		r.Remaining = 0
+	}
+}
+
+func (r *Report) add(order geminix.Order) {
+	executed, _ := geminix.ParseAmount(order.ExecutedAmount)
+	price, _ := geminix.ParseAmount(order.AvgExecutionPrice)
+
+	r.Filled += executed
+	r.Notional += executed * price
+	r.Children = append(r.Children, order)
+}
+
+// MaxRepost is the number of times a child is posted again after the
+// exchange cancels it because the market moved and it would have taken.
+var MaxRepost = 3
+
+// post places a maker-or-cancel child at the passive price. It returns
+// false if the child is not resting: it filled at once, or every attempt
+// would have taken liquidity, in which case the order returned is the last
+// attempt and is cancelled. Children that are done are added to the report.
+func post(exchange geminix.Exchange, parent Parent, amount float64, report *Report) (geminix.Order, bool, error) {
+	options := []string{geminix.MakerOrCancel}
+
+	var order geminix.Order
+	for attempt := 0; attempt <= MaxRepost; attempt++ {
+		price, err := passivePrice(exchange, parent)
+		if err != nil {
+			return geminix.Order{}, false, err
+		}
+
+		order, err = exchange.NewOrder(nil, parent.Symbol, geminix.FormatAmount(amount), nil, geminix.FormatAmount(price),
+			parent.Side, geminix.ExchangeLimit, &options, nil, parent.Account)
+		if err != nil {
+			return order, false, err
+		}
+
+		if order.IsLive {
+			return order, true, nil
+		}
+
+		// A child that is not live either filled or was cancelled for
+		// taking; either way it is done.
+		report.add(order)
+		if !order.IsCancelled {
+			return order, false, nil
+		}
+	}
+
+	return order, false, nil
+}
+
+// passivePrice is the bid when buying and the ask when selling, bounded by
+// the parent's limit price.
+func passivePrice(exchange geminix.Exchange, parent Parent) (float64, error) {
+	ticker, err := exchange.Ticker(parent.Symbol)
+	if err != nil {
+		return 0, err
+	}
+
+	quote := ticker.Bid
+	if parent.Side == geminix.Sell {
+		quote = ticker.Ask
+	}
+	price, err := geminix.ParseAmount(quote)
+	if err != nil || price <= 0 {
+		return 0, fmt.Errorf("execution: no %s price for %s", parent.Side, parent.Symbol)
+	}
+
+	if parent.LimitPrice > 0 {
+		if parent.Side == geminix.Buy && price > parent.LimitPrice {
+			price = parent.LimitPrice
+		}
+		if parent.Side == geminix.Sell && price < parent.LimitPrice {
+			price = parent.LimitPrice
+		}
+	}
+
+	return price, nil
+}
+
+// settle cancels a child if it is still live and returns its final state.
+// A child that fills while it is being cancelled cannot be cancelled, so
+// its state is fetched instead.
+func settle(exchange geminix.Exchange, child geminix.Order, account *string) (geminix.Order, error) {
+	id, err := orderId(child)
+	if err != nil {
+		return child, err
+	}
+
+	order, err := exchange.CancelOrder(id, account)
+	if err == nil {
+		return order, nil
+	}
+
+	var apiErr *geminix.ApiError
+	if !errors.As(err, &apiErr) {
+		return child, err
+	}
+
+	order, statusErr := exchange.OrderStatus(id, nil, nil, account)
+	if statusErr != nil || order.IsLive {
+		return child, err
+	}
+	return order, nil
+}
+
+func orderId(order geminix.Order) (uint, error) {
+	id, err := strconv.ParseUint(order.OrderId, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("execution: invalid order id %q", order.OrderId)
+	}
+	return uint(id), nil
+}
+
+// Wait blocks for d or until ctx is done. It is the default for the Wait
+// field of the executors, which tests and backtests can replace.
+func Wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
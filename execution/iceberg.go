@@ -0,0 +1,210 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	geminix "github.com/Haakam21/gemini-exchange-go"
+)
+
+// Iceberg works a parent order by showing a single clip of it at a time, so
+// that the book never shows more than about DisplayAmount. Each time a clip
+// is done, the next is placed from what remains.
+//
+// Clips are tracked by polling OrderStatus. Passing order events to
+// HandleUpdate makes the iceberg replenish without waiting for the next
+// poll.
+type Iceberg struct {
+	Exchange geminix.Exchange
+	Parent   Parent
+
+	// DisplayAmount is the average size of a clip.
+	DisplayAmount float64
+
+	// Variance randomizes clip sizes by up to this fraction of
+	// DisplayAmount either way, so that the clips do not give the order
+	// away by their size. 0.2 gives clips of 80% to 120% of DisplayAmount.
+	// It must be at least 0 and less than 1.
+	Variance float64
+
+	// PollInterval defaults to one second.
+	PollInterval time.Duration
+
+	// Rand defaults to a source seeded with the time.
+	Rand *rand.Rand
+
+	// Wait is used to back off while every clip would take. It defaults
+	// to the package Wait.
+	Wait func(ctx context.Context, d time.Duration) error
+
+	updates chan geminix.Order
+}
+
+func NewIceberg(exchange geminix.Exchange, parent Parent, displayAmount float64, variance float64) *Iceberg {
+	return &Iceberg{
+		Exchange:      exchange,
+		Parent:        parent,
+		DisplayAmount: displayAmount,
+		Variance:      variance,
+		PollInterval:  time.Second,
+		Rand:          rand.New(rand.NewSource(time.Now().UnixNano())),
+		Wait:          Wait,
+		updates:       make(chan geminix.Order, 64),
+	}
+}
+
+// HandleUpdate passes an order reported by the exchange, such as from an
+// order events feed, to a running iceberg. Orders other than the current
+// clip, and updates older than what is known of it, are ignored. It never
+// blocks.
+func (i *Iceberg) HandleUpdate(order geminix.Order) {
+	select {
+	case i.updates <- order:
+	default:
+	}
+}
+
+// Run places clips until the parent is filled or ctx is done, when the clip
+// that is showing is cancelled.
+func (i *Iceberg) Run(ctx context.Context) (Report, error) {
+	var report Report
+	err := i.run(ctx, &report)
+	report.finish(i.Parent)
+	return report, err
+}
+
+func (i *Iceberg) run(ctx context.Context, report *Report) error {
+	err := i.Parent.validate()
+	if err != nil {
+		return err
+	}
+	if i.DisplayAmount <= 0 {
+		return errors.New("execution: invalid display amount")
+	}
+	if i.Variance < 0 || i.Variance >= 1 {
+		// A variance of 1 or more allows clips of nothing, which would
+		// show the whole parent at once.
+		return fmt.Errorf("execution: invalid variance %v", i.Variance)
+	}
+
+	pollInterval := i.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+
+	wait := i.Wait
+	if wait == nil {
+		wait = Wait
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		remaining := i.Parent.round(i.Parent.Amount - report.Filled)
+		if remaining <= 0 || remaining < i.Parent.MinAmount {
+			return nil
+		}
+
+		clip, live, err := post(i.Exchange, i.Parent, i.clip(remaining), report)
+		if err != nil {
+			return err
+		}
+		if !live {
+			if clip.IsCancelled {
+				// Every attempt would have taken. Try again once the
+				// market has had time to move.
+				if err := wait(ctx, pollInterval); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		clip, err = i.track(ctx, clip, pollInterval)
+		if err != nil {
+			return err
+		}
+		report.add(clip)
+	}
+}
+
+// track waits until a clip is done and returns its final state. If ctx is
+// done first, the clip is cancelled.
+func (i *Iceberg) track(ctx context.Context, clip geminix.Order, pollInterval time.Duration) (geminix.Order, error) {
+	timer := time.NewTimer(pollInterval)
+	defer timer.Stop()
+
+	for clip.IsLive {
+		select {
+		case <-ctx.Done():
+			final, err := settle(i.Exchange, clip, i.Parent.Account)
+			if err != nil {
+				return clip, err
+			}
+			return final, nil
+
+		case order := <-i.updates:
+			if order.OrderId == clip.OrderId && !stale(order, clip) {
+				clip = order
+			}
+
+		case <-timer.C:
+			id, err := orderId(clip)
+			if err != nil {
+				return clip, err
+			}
+			clip, err = i.Exchange.OrderStatus(id, nil, nil, i.Parent.Account)
+			if err != nil {
+				return clip, err
+			}
+			timer.Reset(pollInterval)
+		}
+	}
+
+	return clip, nil
+}
+
+// stale reports whether an update is older than the state of the clip
+// already known, as when it was queued before the last poll.
+func stale(update geminix.Order, clip geminix.Order) bool {
+	if update.Timestampms < clip.Timestampms {
+		return true
+	}
+
+	executed, _ := geminix.ParseAmount(update.ExecutedAmount)
+	known, _ := geminix.ParseAmount(clip.ExecutedAmount)
+	return executed < known
+}
+
+// clip returns the size of the next clip: DisplayAmount varied at random,
+// and all of remaining if less than the minimum would be left over.
+func (i *Iceberg) clip(remaining float64) float64 {
+	size := i.DisplayAmount
+	if i.Variance > 0 {
+		random := i.Rand
+		if random == nil {
+			random = rand.New(rand.NewSource(time.Now().UnixNano()))
+			i.Rand = random
+		}
+		size *= 1 + i.Variance*(2*random.Float64()-1)
+	}
+
+	size = i.Parent.round(size)
+	if size <= 0 {
+		return remaining
+	}
+	if size < i.Parent.MinAmount {
+		size = i.Parent.MinAmount
+	}
+
+	leftover := i.Parent.round(remaining - size)
+	if leftover <= 0 || leftover < i.Parent.MinAmount {
+		return remaining
+	}
+	return size
+}
@@ -0,0 +1,77 @@
+package execution
+
+import (
+	"time"
+
+	geminix "github.com/Haakam21/gemini-exchange-go"
+)
+
+// CandleProfile returns the share of daily volume traded in each of slices
+// intervals starting at the time of day of start, averaged over the days
+// the candles cover. Each candle counts towards the interval its period
+// starts in, so candles should be no longer than the interval, and the
+// schedule should span no more than a day.
+//
+// For a VWAP starting now:
+//
+//	candles, err := client.Candles(symbol, geminix.FiveMinutes)
+//	profile := execution.CandleProfile(candles, time.Now(), time.Hour/12, 12)
+//	slicer := execution.VWAP(client, parent, time.Hour, profile)
+func CandleProfile(candles []geminix.Candle, start time.Time, interval time.Duration, slices int) []float64 {
+	profile := make([]float64, slices)
+	for _, candle := range candles {
+		addVolume(profile, candle.Time(), candle.Volume, start, interval)
+	}
+	return normalize(profile)
+}
+
+// TradeProfile is CandleProfile for individual trades, such as those
+// returned by Client.Trades.
+func TradeProfile(trades []geminix.Trade, start time.Time, interval time.Duration, slices int) []float64 {
+	profile := make([]float64, slices)
+	for _, trade := range trades {
+		amount, err := geminix.ParseAmount(trade.Amount)
+		if err != nil {
+			continue
+		}
+		at := time.Unix(0, int64(trade.Timestampms)*int64(time.Millisecond))
+		addVolume(profile, at, amount, start, interval)
+	}
+	return normalize(profile)
+}
+
+const day = 24 * time.Hour
+
+func addVolume(profile []float64, at time.Time, volume float64, start time.Time, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	offset := (timeOfDay(at) - timeOfDay(start) + day) % day
+	if slice := int(offset / interval); slice < len(profile) {
+		profile[slice] += volume
+	}
+}
+
+func timeOfDay(t time.Time) time.Duration {
+	t = t.UTC()
+	return t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC))
+}
+
+// normalize scales the profile to sum to one. Without any volume every
+// interval gets an equal share.
+func normalize(profile []float64) []float64 {
+	var total float64
+	for _, volume := range profile {
+		total += volume
+	}
+
+	for i := range profile {
+		if total > 0 {
+			profile[i] /= total
+		} else {
+			profile[i] = 1 / float64(len(profile))
+		}
+	}
+	return profile
+}
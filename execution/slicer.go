@@ -0,0 +1,125 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	geminix "github.com/Haakam21/gemini-exchange-go"
+)
+
+// Slicer works a parent order over equal intervals. By the end of each
+// interval it aims to have filled the parent's amount times the weights of
+// the intervals so far, so a child that fills short is made up by the next.
+type Slicer struct {
+	Exchange geminix.Exchange
+	Parent   Parent
+
+	// Weights is the relative share of the parent for each interval.
+	Weights  []float64
+	Interval time.Duration
+
+	// Wait defaults to the package Wait.
+	Wait func(ctx context.Context, d time.Duration) error
+}
+
+// TWAP returns a slicer that spreads the parent evenly over duration.
+func TWAP(exchange geminix.Exchange, parent Parent, duration time.Duration, slices int) *Slicer {
+	// A slices of zero or less leaves no weights, which Run reports.
+	var weights []float64
+	for i := 0; i < slices; i++ {
+		weights = append(weights, 1)
+	}
+
+	return VWAP(exchange, parent, duration, weights)
+}
+
+// VWAP returns a slicer that spreads the parent over duration in proportion
+// to a volume profile, with one interval per weight. CandleProfile and
+// TradeProfile build profiles from historical volume.
+func VWAP(exchange geminix.Exchange, parent Parent, duration time.Duration, profile []float64) *Slicer {
+	s := &Slicer{
+		Exchange: exchange,
+		Parent:   parent,
+		Weights:  append([]float64(nil), profile...),
+		Wait:     Wait,
+	}
+	if len(profile) > 0 {
+		s.Interval = duration / time.Duration(len(profile))
+	}
+	return s
+}
+
+// Run works the parent order until the last interval ends or ctx is done.
+// The child resting at that point is cancelled. Whatever is not filled by
+// then is left in the report's Remaining.
+func (s *Slicer) Run(ctx context.Context) (Report, error) {
+	var report Report
+	err := s.run(ctx, &report)
+	report.finish(s.Parent)
+	return report, err
+}
+
+func (s *Slicer) run(ctx context.Context, report *Report) error {
+	err := s.Parent.validate()
+	if err != nil {
+		return err
+	}
+
+	var total float64
+	for _, weight := range s.Weights {
+		if weight < 0 {
+			return errors.New("execution: negative weight")
+		}
+		total += weight
+	}
+	if total == 0 {
+		return errors.New("execution: no weights")
+	}
+	if s.Interval <= 0 {
+		return errors.New("execution: invalid interval")
+	}
+
+	wait := s.Wait
+	if wait == nil {
+		wait = Wait
+	}
+
+	var cumulative float64
+	for i, weight := range s.Weights {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		cumulative += weight
+		target := s.Parent.Amount * cumulative / total
+		if i == len(s.Weights)-1 {
+			target = s.Parent.Amount
+		}
+
+		var child geminix.Order
+		var live bool
+		if amount := s.Parent.round(target - report.Filled); amount > 0 && amount >= s.Parent.MinAmount {
+			child, live, err = post(s.Exchange, s.Parent, amount, report)
+			if err != nil {
+				return err
+			}
+		}
+
+		waitErr := wait(ctx, s.Interval)
+
+		if live {
+			child, err = settle(s.Exchange, child, s.Parent.Account)
+			if err != nil {
+				return err
+			}
+			report.add(child)
+		}
+
+		if waitErr != nil {
+			return waitErr
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,243 @@
+package geminix
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+var (
+	TradeColumns = []string{
+		"timestamp", "timestampms", "symbol", "tid", "order_id", "client_order_id", "type",
+		"price", "amount", "quote_currency", "quote_amount", "fee_currency", "fee_amount",
+		"aggressor", "is_auction_fill", "exchange", "break", "fiat", "fiat_value", "fee_fiat_value",
+	}
+
+	FeeColumns = []string{
+		"timestamp", "timestampms", "symbol", "tid", "order_id", "fee_currency", "fee_amount",
+		"fiat", "fee_fiat_value",
+	}
+
+	TransferColumns = []string{
+		"timestamp", "timestampms", "eid", "type", "status", "currency", "amount", "method",
+		"tx_hash", "output_idx", "destination", "purpose", "advance_eid", "fiat", "fiat_value",
+	}
+)
+
+// ExportOptions controls the optional fiat valuation columns. When Valuer is
+// nil the columns are still written, but left empty, as are the fee values
+// of trades without a fee.
+type ExportOptions struct {
+	Fiat   Currency
+	Valuer Valuer
+}
+
+func (o ExportOptions) value(currency Currency, amount float64, at time.Time) (string, string, error) {
+	if o.Valuer == nil {
+		return "", "", nil
+	}
+
+	value, err := o.Valuer.Value(currency, amount, at)
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(o.Fiat), FormatAmount(value), nil
+}
+
+// feeValue values a trade's fee. Trades without a fee are not valued, so
+// the Valuer is never asked for a price it does not need.
+func (o ExportOptions) feeValue(trade Trade, feeAmount float64, at time.Time) (string, string, error) {
+	if feeAmount == 0 || trade.FeeCurrency == "" {
+		return "", "", nil
+	}
+
+	return o.value(Currency(trade.FeeCurrency), feeAmount, at)
+}
+
+func formatTimestamp(timestampms uint64) string {
+	return time.Unix(0, int64(timestampms)*int64(time.Millisecond)).UTC().Format("2006-01-02T15:04:05.000Z07:00")
+}
+
+func formatUint(u uint64) string {
+	return strconv.FormatUint(u, 10)
+}
+
+func formatBool(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+func tradeRow(trade Trade, opts ExportOptions) ([]string, error) {
+	at := time.Unix(0, int64(trade.Timestampms)*int64(time.Millisecond))
+
+	price, err := ParseAmount(trade.Price)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := ParseAmount(trade.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	feeAmount, err := ParseAmount(trade.FeeAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := Symbol(trade.Symbol).Quote()
+	quoteAmount := price * amount
+
+	fiat, fiatValue, err := opts.value(quote, quoteAmount, at)
+	if err != nil {
+		return nil, err
+	}
+
+	_, feeFiatValue, err := opts.feeValue(trade, feeAmount, at)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{
+		formatTimestamp(trade.Timestampms), formatUint(trade.Timestampms), trade.Symbol, formatUint(uint64(trade.Tid)),
+		trade.OrderId, trade.ClientOrderId, trade.Type, trade.Price, trade.Amount, string(quote), FormatAmount(quoteAmount),
+		trade.FeeCurrency, trade.FeeAmount, formatBool(trade.Aggressor), formatBool(trade.IsAuctionFill),
+		trade.Exchange, trade.Break, fiat, fiatValue, feeFiatValue,
+	}, nil
+}
+
+func feeRow(trade Trade, opts ExportOptions) ([]string, error) {
+	at := time.Unix(0, int64(trade.Timestampms)*int64(time.Millisecond))
+
+	feeAmount, err := ParseAmount(trade.FeeAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	fiat, feeFiatValue, err := opts.feeValue(trade, feeAmount, at)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{
+		formatTimestamp(trade.Timestampms), formatUint(trade.Timestampms), trade.Symbol, formatUint(uint64(trade.Tid)),
+		trade.OrderId, trade.FeeCurrency, trade.FeeAmount, fiat, feeFiatValue,
+	}, nil
+}
+
+func transferRow(transfer Transfer, opts ExportOptions) ([]string, error) {
+	at := time.Unix(0, int64(transfer.Timestampms)*int64(time.Millisecond))
+
+	amount, err := ParseAmount(transfer.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	fiat, fiatValue, err := opts.value(transfer.Currency, amount, at)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{
+		formatTimestamp(transfer.Timestampms), formatUint(transfer.Timestampms), formatUint(uint64(transfer.EID)),
+		transfer.Type, transfer.Status, string(transfer.Currency), transfer.Amount, transfer.Method, transfer.TxHash,
+		formatUint(uint64(transfer.OutputIdx)), transfer.Destination, transfer.Purpose, formatUint(uint64(transfer.AdvanceEid)),
+		fiat, fiatValue,
+	}, nil
+}
+
+func writeCSV(w io.Writer, columns []string, n int, row func(i int) ([]string, error)) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write(columns)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < n; i++ {
+		record, err := row(i)
+		if err != nil {
+			return err
+		}
+
+		err = writer.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeJSONL writes one JSON object per line, with keys in column order and
+// all values encoded as strings so the schema does not depend on the data.
+func writeJSONL(w io.Writer, columns []string, n int, row func(i int) ([]string, error)) error {
+	writer := bufio.NewWriter(w)
+
+	for i := 0; i < n; i++ {
+		record, err := row(i)
+		if err != nil {
+			return err
+		}
+
+		writer.WriteByte('{')
+		for j, column := range columns {
+			if j > 0 {
+				writer.WriteByte(',')
+			}
+
+			key, _ := json.Marshal(column)
+			value, _ := json.Marshal(record[j])
+
+			writer.Write(key)
+			writer.WriteByte(':')
+			writer.Write(value)
+		}
+		writer.WriteString("}\n")
+	}
+
+	return writer.Flush()
+}
+
+func WriteTradesCSV(w io.Writer, trades []Trade, opts ExportOptions) error {
+	return writeCSV(w, TradeColumns, len(trades), func(i int) ([]string, error) {
+		return tradeRow(trades[i], opts)
+	})
+}
+
+func WriteTradesJSONL(w io.Writer, trades []Trade, opts ExportOptions) error {
+	return writeJSONL(w, TradeColumns, len(trades), func(i int) ([]string, error) {
+		return tradeRow(trades[i], opts)
+	})
+}
+
+func WriteFeesCSV(w io.Writer, trades []Trade, opts ExportOptions) error {
+	return writeCSV(w, FeeColumns, len(trades), func(i int) ([]string, error) {
+		return feeRow(trades[i], opts)
+	})
+}
+
+func WriteFeesJSONL(w io.Writer, trades []Trade, opts ExportOptions) error {
+	return writeJSONL(w, FeeColumns, len(trades), func(i int) ([]string, error) {
+		return feeRow(trades[i], opts)
+	})
+}
+
+func WriteTransfersCSV(w io.Writer, transfers []Transfer, opts ExportOptions) error {
+	return writeCSV(w, TransferColumns, len(transfers), func(i int) ([]string, error) {
+		return transferRow(transfers[i], opts)
+	})
+}
+
+func WriteTransfersJSONL(w io.Writer, transfers []Transfer, opts ExportOptions) error {
+	return writeJSONL(w, TransferColumns, len(transfers), func(i int) ([]string, error) {
+		return transferRow(transfers[i], opts)
+	})
+}
@@ -0,0 +1,363 @@
+package geminitest
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	geminix "github.com/Haakam21/gemini-exchange-go"
+)
+
+type order struct {
+	id            uint64
+	clientOrderId string
+	account       *account
+	symbol        geminix.Symbol
+	side          string
+	price         float64
+	original      float64
+	executed      float64
+	notional      float64
+	options       []string
+	timestampms   uint64
+	live          bool
+	cancelled     bool
+	reason        string
+	trades        []geminix.Trade
+}
+
+func (o *order) remaining() float64 {
+	return o.original - o.executed
+}
+
+func (o *order) hasOption(option string) bool {
+	for _, o := range o.options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *order) toOrder(includeTrades bool) geminix.Order {
+	var avgExecutionPrice float64
+	if o.executed > 0 {
+		avgExecutionPrice = o.notional / o.executed
+	}
+
+	order := geminix.Order{
+		OrderId:           strconv.FormatUint(o.id, 10),
+		ClientOrderId:     o.clientOrderId,
+		Symbol:            strings.ToLower(string(o.symbol)),
+		Exchange:          "gemini",
+		Price:             geminix.FormatAmount(o.price),
+		AvgExecutionPrice: geminix.FormatAmount(avgExecutionPrice),
+		Side:              o.side,
+		Type:              "exchange limit",
+		Options:           append([]string{}, o.options...),
+		Timestamp:         strconv.FormatUint(o.timestampms/1000, 10),
+		Timestampms:       o.timestampms,
+		IsLive:            o.live,
+		IsCancelled:       o.cancelled,
+		Reason:            o.reason,
+		ExecutedAmount:    geminix.FormatAmount(o.executed),
+		RemainingAmount:   geminix.FormatAmount(o.remaining()),
+		OriginalAmount:    geminix.FormatAmount(o.original),
+	}
+
+	if includeTrades {
+		order.Trades = append([]geminix.Trade{}, o.trades...)
+	}
+
+	return order
+}
+
+// book holds resting orders, best price first and in time priority within a
+// price level.
+type book struct {
+	bids   []*order
+	asks   []*order
+	last   float64
+	trades []geminix.Trade
+}
+
+func (b *book) side(side string) *[]*order {
+	if side == "buy" {
+		return &b.bids
+	}
+	return &b.asks
+}
+
+func (b *book) insert(o *order) {
+	orders := b.side(o.side)
+
+	i := sort.Search(len(*orders), func(i int) bool {
+		if o.side == "buy" {
+			return (*orders)[i].price < o.price
+		}
+		return (*orders)[i].price > o.price
+	})
+
+	*orders = append(*orders, nil)
+	copy((*orders)[i+1:], (*orders)[i:])
+	(*orders)[i] = o
+}
+
+func (b *book) remove(o *order) {
+	orders := b.side(o.side)
+
+	for i, resting := range *orders {
+		if resting == o {
+			*orders = append((*orders)[:i], (*orders)[i+1:]...)
+			return
+		}
+	}
+}
+
+func tradeType(side string) string {
+	if side == "buy" {
+		return "Buy"
+	}
+	return "Sell"
+}
+
+func crosses(taker *order, maker *order) bool {
+	if taker.side == "buy" {
+		return maker.price <= taker.price
+	}
+	return maker.price >= taker.price
+}
+
+func (s *Server) book(symbol geminix.Symbol) *book {
+	b, ok := s.books[symbol]
+	if !ok {
+		b = &book{}
+		s.books[symbol] = b
+	}
+	return b
+}
+
+// submit validates funds and matches a new order against the book, resting
+// any remainder unless its options forbid it.
+func (s *Server) submit(o *order) *failure {
+	base, quote := o.symbol.Split()
+	if base == "" {
+		return fail(http.StatusBadRequest, "InvalidSymbol", "Invalid symbol "+string(o.symbol))
+	}
+
+	if o.side == "buy" {
+		needed := o.price * o.original * (1 + s.TakerFeeBps/10000)
+		if b := o.account.balance(quote); b.amount-b.held < needed {
+			return fail(http.StatusBadRequest, "InsufficientFunds", "Failed to place buy order on symbol '"+string(o.symbol)+"' for price $"+geminix.FormatAmount(o.price)+" and quantity "+geminix.FormatAmount(o.original)+" due to insufficient funds")
+		}
+	} else {
+		if b := o.account.balance(base); b.amount-b.held < o.original {
+			return fail(http.StatusBadRequest, "InsufficientFunds", "Failed to place sell order on symbol '"+string(o.symbol)+"' for price $"+geminix.FormatAmount(o.price)+" and quantity "+geminix.FormatAmount(o.original)+" due to insufficient funds")
+		}
+	}
+
+	s.nextId++
+	o.id = s.nextId
+	o.timestampms = s.nowms()
+	s.orders[o.id] = o
+
+	b := s.book(o.symbol)
+	opposite := b.side("sell")
+	if o.side == "sell" {
+		opposite = b.side("buy")
+	}
+
+	if o.hasOption("maker-or-cancel") && len(*opposite) > 0 && crosses(o, (*opposite)[0]) {
+		o.cancelled = true
+		o.reason = "MakerOrCancelWouldTake"
+		return nil
+	}
+
+	if o.hasOption("fill-or-kill") {
+		var available float64
+		for _, maker := range *opposite {
+			if !crosses(o, maker) {
+				break
+			}
+			available += maker.remaining()
+		}
+		if available < o.original {
+			o.cancelled = true
+			o.reason = "FillOrKillWouldNotFill"
+			return nil
+		}
+	}
+
+	for o.remaining() > 0 && len(*opposite) > 0 && crosses(o, (*opposite)[0]) {
+		maker := (*opposite)[0]
+
+		amount := o.remaining()
+		if maker.remaining() < amount {
+			amount = maker.remaining()
+		}
+
+		s.fill(o, maker, amount, maker.price)
+
+		if maker.remaining() <= 0 {
+			maker.live = false
+			b.remove(maker)
+		}
+	}
+
+	if o.remaining() <= 0 {
+		return nil
+	}
+
+	if o.hasOption("immediate-or-cancel") {
+		o.cancelled = true
+		o.reason = "ImmediateOrCancelWouldPost"
+		return nil
+	}
+
+	if o.side == "buy" {
+		o.account.balance(quote).held += o.remaining() * o.price
+	} else {
+		o.account.balance(base).held += o.remaining()
+	}
+
+	o.live = true
+	b.insert(o)
+
+	return nil
+}
+
+func (s *Server) fill(taker *order, maker *order, amount float64, price float64) {
+	b := s.book(taker.symbol)
+	b.last = price
+
+	s.settle(taker, amount, price, false)
+	s.settle(maker, amount, price, true)
+
+	trade := taker.trades[len(taker.trades)-1]
+	b.trades = append(b.trades, geminix.Trade{
+		Timestamp:   trade.Timestamp,
+		Timestampms: trade.Timestampms,
+		Tid:         trade.Tid,
+		Price:       trade.Price,
+		Amount:      trade.Amount,
+		Exchange:    "gemini",
+		Type:        taker.side,
+	})
+}
+
+func (s *Server) settle(o *order, amount float64, price float64, isMaker bool) {
+	base, quote := o.symbol.Split()
+
+	feeBps := s.TakerFeeBps
+	if isMaker {
+		feeBps = s.MakerFeeBps
+	}
+
+	notional := amount * price
+	fee := notional * feeBps / 10000
+
+	if o.side == "buy" {
+		o.account.balance(base).amount += amount
+		o.account.balance(quote).amount -= notional + fee
+		if isMaker {
+			o.account.balance(quote).held -= amount * o.price
+		}
+	} else {
+		o.account.balance(base).amount -= amount
+		o.account.balance(quote).amount += notional - fee
+		if isMaker {
+			o.account.balance(base).held -= amount
+		}
+	}
+
+	o.executed += amount
+	o.notional += notional
+
+	now := s.Now()
+	s.nextTid++
+
+	trade := geminix.Trade{
+		Symbol:        strings.ToLower(string(o.symbol)),
+		Price:         geminix.FormatAmount(price),
+		Amount:        geminix.FormatAmount(amount),
+		Timestamp:     uint64(now.Unix()),
+		Timestampms:   uint64(now.UnixNano() / int64(time.Millisecond)),
+		Type:          tradeType(o.side),
+		Aggressor:     !isMaker,
+		FeeCurrency:   string(quote),
+		FeeAmount:     geminix.FormatAmount(fee),
+		Tid:           s.nextTid,
+		OrderId:       strconv.FormatUint(o.id, 10),
+		ClientOrderId: o.clientOrderId,
+		Exchange:      "gemini",
+	}
+
+	o.trades = append(o.trades, trade)
+	o.account.trades = append(o.account.trades, trade)
+}
+
+func (s *Server) cancel(o *order) {
+	if !o.live {
+		return
+	}
+
+	base, quote := o.symbol.Split()
+	if o.side == "buy" {
+		o.account.balance(quote).held -= o.remaining() * o.price
+	} else {
+		o.account.balance(base).held -= o.remaining()
+	}
+
+	o.live = false
+	o.cancelled = true
+	o.reason = "Requested"
+	s.book(o.symbol).remove(o)
+}
+
+func (s *Server) ticker(symbol geminix.Symbol) geminix.Ticker {
+	if ticker, ok := s.tickers[symbol]; ok {
+		return ticker
+	}
+
+	var ticker geminix.Ticker
+
+	b, ok := s.books[symbol]
+	if !ok {
+		return ticker
+	}
+
+	if len(b.bids) > 0 {
+		ticker.Bid = geminix.FormatAmount(b.bids[0].price)
+	}
+	if len(b.asks) > 0 {
+		ticker.Ask = geminix.FormatAmount(b.asks[0].price)
+	}
+	if b.last > 0 {
+		ticker.Last = geminix.FormatAmount(b.last)
+	}
+
+	return ticker
+}
+
+// PlaceOrder places a limit order directly on the book on behalf of an
+// account, bypassing authentication. It is intended for seeding liquidity.
+func (s *Server) PlaceOrder(account string, symbol geminix.Symbol, side string, amount float64, price float64) (geminix.Order, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	o := &order{
+		account:  s.account(account),
+		symbol:   normalizeSymbol(symbol),
+		side:     strings.ToLower(side),
+		price:    price,
+		original: amount,
+	}
+
+	if f := s.submit(o); f != nil {
+		return geminix.Order{}, &geminix.ApiError{Reason: f.reason, Message: f.message}
+	}
+
+	return o.toOrder(false), nil
+}
@@ -0,0 +1,602 @@
+package geminitest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	geminix "github.com/Haakam21/gemini-exchange-go"
+)
+
+type call struct {
+	server  *Server
+	w       http.ResponseWriter
+	key     *apiKey
+	account *account
+	params  map[string]interface{}
+}
+
+func (c *call) ok(v interface{}) {
+	writeJSON(c.w, http.StatusOK, v)
+}
+
+func (c *call) fail(f *failure) {
+	writeError(c.w, f.status, f.reason, f.message)
+}
+
+type route struct {
+	uri    string
+	master bool
+	handle func(c *call, arg string)
+}
+
+var routes = []route{
+	{geminix.NewOrderUri, false, (*call).newOrder},
+	{geminix.CancelOrderUri, false, (*call).cancelOrder},
+	{geminix.CancelSessionUri, false, (*call).cancelAll},
+	{geminix.CancelAllUri, false, (*call).cancelAll},
+	{geminix.OrderStatusUri, false, (*call).orderStatus},
+	{geminix.ActiveOrdersUri, false, (*call).activeOrders},
+	{geminix.PastTradesUri, false, (*call).pastTrades},
+	{geminix.BalancesUri, false, (*call).balances},
+	{geminix.NotionalBalancesUri, false, (*call).notionalBalances},
+	{geminix.TransfersUri, false, (*call).transfers},
+	{geminix.WithdrawCryptoUri, false, (*call).withdrawCrypto},
+	{geminix.DepositAddressesUri, false, (*call).depositAddresses},
+	{geminix.InternalTransferUri, true, (*call).internalTransfer},
+	{geminix.RequestAddressUri, false, (*call).requestAddress},
+	{geminix.ApprovedAddressesUri, false, (*call).approvedAddresses},
+	{geminix.AccountDetailUri, false, (*call).accountDetail},
+	{geminix.CreateAccountUri, true, (*call).createAccount},
+	{geminix.AccountsUri, true, (*call).accounts},
+}
+
+func (s *Server) handleTicker(w http.ResponseWriter, symbol geminix.Symbol) {
+	symbol = normalizeSymbol(symbol)
+
+	base, quote := symbol.Split()
+	if base == "" {
+		writeError(w, http.StatusBadRequest, "InvalidSymbol", "Supplied value '"+string(symbol)+"' is not a valid symbol")
+		return
+	}
+
+	ticker := s.ticker(symbol)
+	ticker.Volume = map[string]interface{}{
+		string(base):  "0",
+		string(quote): "0",
+		"timestamp":   s.nowms(),
+	}
+
+	writeJSON(w, http.StatusOK, ticker)
+}
+
+func bookEntries(orders []*order, limit int) []geminix.BookEntry {
+	entries := []geminix.BookEntry{}
+	for _, o := range orders {
+		price := geminix.FormatAmount(o.price)
+		if n := len(entries); n > 0 && entries[n-1].Price == price {
+			amount, _ := geminix.ParseAmount(entries[n-1].Amount)
+			entries[n-1].Amount = geminix.FormatAmount(amount + o.remaining())
+			continue
+		}
+		if limit > 0 && len(entries) == limit {
+			break
+		}
+		entries = append(entries, geminix.BookEntry{
+			Price:     price,
+			Amount:    geminix.FormatAmount(o.remaining()),
+			Timestamp: strconv.FormatUint(o.timestampms/1000, 10),
+		})
+	}
+	return entries
+}
+
+func (s *Server) handleOrderBook(w http.ResponseWriter, r *http.Request, symbol geminix.Symbol) {
+	symbol = normalizeSymbol(symbol)
+
+	if base, _ := symbol.Split(); base == "" {
+		writeError(w, http.StatusBadRequest, "InvalidSymbol", "Supplied value '"+string(symbol)+"' is not a valid symbol")
+		return
+	}
+
+	limitBids, limitAsks := 50, 50
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit_bids")); err == nil {
+		limitBids = limit
+	}
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit_asks")); err == nil {
+		limitAsks = limit
+	}
+
+	b := s.book(symbol)
+	writeJSON(w, http.StatusOK, geminix.OrderBook{
+		Bids: bookEntries(b.bids, limitBids),
+		Asks: bookEntries(b.asks, limitAsks),
+	})
+}
+
+func (s *Server) handleTrades(w http.ResponseWriter, r *http.Request, symbol geminix.Symbol) {
+	symbol = normalizeSymbol(symbol)
+	trades := s.book(symbol).trades
+	query := r.URL.Query()
+
+	params := map[string]interface{}{}
+	for _, key := range []string{"timestamp", "since_tid", "limit_trades"} {
+		if value := query.Get(key); value != "" {
+			params[key] = value
+		}
+	}
+
+	sinceTid, hasSinceTid := uintParam(params, "since_tid")
+	since, hasSince := timestampParam(params, "timestamp")
+	limit := limitParam(params, "limit_trades", 50, geminix.MaxLimitTrades)
+
+	var filtered []geminix.Trade
+	for _, trade := range trades {
+		if hasSinceTid && uint64(trade.Tid) <= sinceTid {
+			continue
+		}
+		filtered = append(filtered, trade)
+	}
+
+	result := []geminix.Trade{}
+	for _, i := range page(len(filtered), func(i int) uint64 { return filtered[i].Timestampms }, since, hasSince, limit) {
+		result = append(result, filtered[i])
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleCandles serves candles newest first, as the exchange does.
+func (s *Server) handleCandles(w http.ResponseWriter, symbol geminix.Symbol, timeFrame geminix.TimeFrame) {
+	if timeFrame.Duration() == 0 {
+		writeError(w, http.StatusBadRequest, "InvalidTimeFrame", "Invalid time frame "+string(timeFrame))
+		return
+	}
+
+	candles := append([]geminix.Candle{}, s.candles[string(normalizeSymbol(symbol))+"/"+string(timeFrame)]...)
+	sort.Slice(candles, func(i, j int) bool { return candles[i].Timestamp > candles[j].Timestamp })
+
+	writeJSON(w, http.StatusOK, candles)
+}
+
+func (c *call) newOrder(arg string) {
+	amount, err := geminix.ParseAmount(stringParam(c.params, "amount"))
+	if err != nil || amount <= 0 {
+		c.fail(fail(http.StatusBadRequest, "InvalidQuantity", "Invalid quantity for symbol"))
+		return
+	}
+
+	price, err := geminix.ParseAmount(stringParam(c.params, "price"))
+	if err != nil || price <= 0 {
+		c.fail(fail(http.StatusBadRequest, "InvalidPrice", "Invalid price for symbol"))
+		return
+	}
+
+	side := stringParam(c.params, "side")
+	if side != "buy" && side != "sell" {
+		c.fail(fail(http.StatusBadRequest, "InvalidSide", "Invalid side for symbol"))
+		return
+	}
+
+	if Type := stringParam(c.params, "type"); Type != "exchange limit" {
+		c.fail(fail(http.StatusBadRequest, "InvalidOrderType", "Unsupported order type "+Type))
+		return
+	}
+
+	o := &order{
+		clientOrderId: stringParam(c.params, "client_order_id"),
+		account:       c.account,
+		symbol:        normalizeSymbol(geminix.Symbol(stringParam(c.params, "symbol"))),
+		side:          side,
+		price:         price,
+		original:      amount,
+		options:       stringsParam(c.params, "options"),
+	}
+
+	if f := c.server.submit(o); f != nil {
+		c.fail(f)
+		return
+	}
+
+	c.ok(o.toOrder(false))
+}
+
+func (c *call) findOrder() (*order, *failure) {
+	if id, ok := uintParam(c.params, "order_id"); ok && id != 0 {
+		o, ok := c.server.orders[id]
+		if !ok || o.account != c.account {
+			return nil, fail(http.StatusBadRequest, "OrderNotFound", fmt.Sprintf("Order %d not found", id))
+		}
+		return o, nil
+	}
+
+	if clientOrderId := stringParam(c.params, "client_order_id"); clientOrderId != "" {
+		var found *order
+		for _, o := range c.server.orders {
+			if o.account == c.account && o.clientOrderId == clientOrderId && (found == nil || o.id > found.id) {
+				found = o
+			}
+		}
+		if found == nil {
+			return nil, fail(http.StatusBadRequest, "OrderNotFound", "Order with client order id "+clientOrderId+" not found")
+		}
+		return found, nil
+	}
+
+	return nil, fail(http.StatusBadRequest, "MissingOrderField", "Missing order_id or client_order_id")
+}
+
+func (c *call) cancelOrder(arg string) {
+	o, f := c.findOrder()
+	if f != nil {
+		c.fail(f)
+		return
+	}
+
+	c.server.cancel(o)
+	c.ok(o.toOrder(false))
+}
+
+// cancelAll serves both cancel all and cancel session; the fake has no notion
+// of sessions.
+func (c *call) cancelAll(arg string) {
+	cancelled := []uint64{}
+	for _, o := range c.liveOrders() {
+		c.server.cancel(o)
+		cancelled = append(cancelled, o.id)
+	}
+
+	c.ok(geminix.CancelResult{
+		Result: "ok",
+		Details: geminix.CancelResultDetails{
+			CancelledOrders: cancelled,
+			CancelRejects:   []uint64{},
+		},
+	})
+}
+
+func (c *call) orderStatus(arg string) {
+	o, f := c.findOrder()
+	if f != nil {
+		c.fail(f)
+		return
+	}
+
+	c.ok(o.toOrder(boolParam(c.params, "include_trades")))
+}
+
+func (c *call) liveOrders() []*order {
+	var orders []*order
+	for _, o := range c.server.orders {
+		if o.account == c.account && o.live {
+			orders = append(orders, o)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].id < orders[j].id })
+	return orders
+}
+
+func (c *call) activeOrders(arg string) {
+	orders := []geminix.Order{}
+	for _, o := range c.liveOrders() {
+		orders = append(orders, o.toOrder(false))
+	}
+
+	c.ok(orders)
+}
+
+// timestampParam reads a timestamp in seconds or milliseconds and returns it
+// in milliseconds.
+func timestampParam(params map[string]interface{}, key string) (uint64, bool) {
+	timestamp, ok := uintParam(params, key)
+	if !ok {
+		return 0, false
+	}
+	if timestamp < 100000000000 {
+		timestamp *= 1000
+	}
+	return timestamp, true
+}
+
+func limitParam(params map[string]interface{}, key string, def uint64, max uint64) uint64 {
+	limit, ok := uintParam(params, key)
+	if !ok || limit == 0 {
+		return def
+	}
+	if limit > max {
+		return max
+	}
+	return limit
+}
+
+// page returns up to limit indexes of records sorted oldest first. With a
+// timestamp it returns the oldest records on or after it, otherwise the most
+// recent ones. Either way the result is ordered newest first.
+func page(n int, timestampms func(i int) uint64, since uint64, hasSince bool, limit uint64) []int {
+	var indexes []int
+	for i := 0; i < n; i++ {
+		if !hasSince || timestampms(i) >= since {
+			indexes = append(indexes, i)
+		}
+	}
+
+	if uint64(len(indexes)) > limit {
+		if hasSince {
+			indexes = indexes[:limit]
+		} else {
+			indexes = indexes[uint64(len(indexes))-limit:]
+		}
+	}
+
+	for i, j := 0, len(indexes)-1; i < j; i, j = i+1, j-1 {
+		indexes[i], indexes[j] = indexes[j], indexes[i]
+	}
+
+	return indexes
+}
+
+func (c *call) pastTrades(arg string) {
+	symbol := strings.ToLower(stringParam(c.params, "symbol"))
+	if symbol == "" {
+		c.fail(fail(http.StatusBadRequest, "MissingSymbol", "Missing symbol"))
+		return
+	}
+
+	var trades []geminix.Trade
+	for _, trade := range c.account.trades {
+		if trade.Symbol == symbol {
+			trades = append(trades, trade)
+		}
+	}
+
+	since, hasSince := timestampParam(c.params, "timestamp")
+	limit := limitParam(c.params, "limit_trades", 50, geminix.MaxLimitTrades)
+
+	result := []geminix.Trade{}
+	for _, i := range page(len(trades), func(i int) uint64 { return trades[i].Timestampms }, since, hasSince, limit) {
+		result = append(result, trades[i])
+	}
+
+	c.ok(result)
+}
+
+func (c *call) balanceList(fiat geminix.Currency) []geminix.Balance {
+	currencies := make([]geminix.Currency, 0, len(c.account.balances))
+	for currency := range c.account.balances {
+		currencies = append(currencies, currency)
+	}
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i] < currencies[j] })
+
+	balances := []geminix.Balance{}
+	for _, currency := range currencies {
+		b := c.account.balances[currency]
+		if b.amount == 0 && b.held == 0 {
+			continue
+		}
+
+		balance := geminix.Balance{
+			Currency:               currency,
+			Amount:                 geminix.FormatAmount(b.amount),
+			Available:              geminix.FormatAmount(b.amount - b.held),
+			AvailableForWithdrawal: geminix.FormatAmount(b.amount - b.held),
+			Type:                   "exchange",
+		}
+
+		if fiat != "" {
+			price := 1.0
+			if currency != fiat {
+				price, _ = geminix.ParseAmount(c.server.ticker(geminix.NewSymbol(currency, fiat)).Last)
+			}
+
+			balance.AmountNotional = geminix.FormatAmount(b.amount * price)
+			balance.AvailableNotional = geminix.FormatAmount((b.amount - b.held) * price)
+			balance.AvailableForWithdrawalNotional = balance.AvailableNotional
+		}
+
+		balances = append(balances, balance)
+	}
+
+	return balances
+}
+
+func (c *call) balances(arg string) {
+	c.ok(c.balanceList(""))
+}
+
+func (c *call) notionalBalances(arg string) {
+	c.ok(c.balanceList(geminix.Currency(strings.ToUpper(arg))))
+}
+
+func (c *call) transfers(arg string) {
+	transfers := c.account.transfers
+
+	since, hasSince := timestampParam(c.params, "timestamp")
+	limit := limitParam(c.params, "limit_transfers", 10, geminix.MaxLimitTransfers)
+
+	result := []geminix.Transfer{}
+	for _, i := range page(len(transfers), func(i int) uint64 { return transfers[i].Timestampms }, since, hasSince, limit) {
+		result = append(result, transfers[i])
+	}
+
+	c.ok(result)
+}
+
+func (c *call) withdrawCrypto(arg string) {
+	currency := geminix.Currency(strings.ToUpper(arg))
+	address := stringParam(c.params, "address")
+
+	amount, err := geminix.ParseAmount(stringParam(c.params, "amount"))
+	if err != nil || amount <= 0 {
+		c.fail(fail(http.StatusBadRequest, "InvalidQuantity", "Invalid withdrawal amount"))
+		return
+	}
+
+	if address == "" {
+		c.fail(fail(http.StatusBadRequest, "InvalidAddress", "Missing withdrawal address"))
+		return
+	}
+
+	b := c.account.balance(currency)
+	if b.amount-b.held < amount {
+		c.fail(fail(http.StatusBadRequest, "InsufficientFunds", "Insufficient funds for withdrawal"))
+		return
+	}
+	b.amount -= amount
+
+	eid := c.server.eid()
+	txHash := fmt.Sprintf("%064x", eid)
+
+	c.account.transfers = append(c.account.transfers, geminix.Transfer{
+		Type:        "Withdrawal",
+		Status:      "Complete",
+		Timestampms: c.server.nowms(),
+		EID:         eid,
+		Currency:    currency,
+		Amount:      geminix.FormatAmount(amount),
+		TxHash:      txHash,
+		Destination: address,
+	})
+
+	c.ok(geminix.CryptoWithdrawal{
+		Address:      address,
+		Amount:       geminix.FormatAmount(amount),
+		TxHash:       txHash,
+		WithdrawalId: fmt.Sprintf("%d", eid),
+	})
+}
+
+func (c *call) depositAddresses(arg string) {
+	addresses := append([]geminix.DepositAddress{}, c.account.depositAddresses[geminix.Network(arg)]...)
+	c.ok(addresses)
+}
+
+func (c *call) internalTransfer(arg string) {
+	currency := geminix.Currency(strings.ToUpper(arg))
+
+	source, ok := c.server.accounts[stringParam(c.params, "sourceAccount")]
+	if !ok {
+		c.fail(fail(http.StatusBadRequest, "AccountNotFound", "Source account not found"))
+		return
+	}
+
+	target, ok := c.server.accounts[stringParam(c.params, "targetAccount")]
+	if !ok {
+		c.fail(fail(http.StatusBadRequest, "AccountNotFound", "Target account not found"))
+		return
+	}
+
+	amount, err := geminix.ParseAmount(stringParam(c.params, "amount"))
+	if err != nil || amount <= 0 {
+		c.fail(fail(http.StatusBadRequest, "InvalidQuantity", "Invalid transfer amount"))
+		return
+	}
+
+	b := source.balance(currency)
+	if b.amount-b.held < amount {
+		c.fail(fail(http.StatusBadRequest, "InsufficientFunds", "Insufficient funds for transfer"))
+		return
+	}
+	b.amount -= amount
+	target.balance(currency).amount += amount
+
+	eid := c.server.eid()
+	now := c.server.nowms()
+
+	source.transfers = append(source.transfers, geminix.Transfer{
+		Type: "Withdrawal", Status: "Complete", Timestampms: now, EID: eid,
+		Currency: currency, Amount: geminix.FormatAmount(amount), Method: "Internal", Destination: target.info.Account,
+	})
+	target.transfers = append(target.transfers, geminix.Transfer{
+		Type: "Deposit", Status: "Complete", Timestampms: now, EID: eid,
+		Currency: currency, Amount: geminix.FormatAmount(amount), Method: "Internal",
+	})
+
+	c.ok(geminix.InternalTransfer{
+		FromAccount:  source.info.Account,
+		ToAccount:    target.info.Account,
+		Amount:       geminix.FormatAmount(amount),
+		Fee:          "0",
+		Currency:     currency,
+		WithdrawalId: fmt.Sprintf("%d", eid),
+		UUID:         fmt.Sprintf("%08x-0000-0000-0000-000000000000", eid),
+		Message:      "Success",
+	})
+}
+
+func (c *call) requestAddress(arg string) {
+	network := geminix.Network(arg)
+	address := stringParam(c.params, "address")
+
+	if address == "" {
+		c.fail(fail(http.StatusBadRequest, "InvalidAddress", "Missing address"))
+		return
+	}
+
+	c.account.approved[network] = append(c.account.approved[network], ApprovedAddress{
+		Network: network,
+		Address: address,
+		Label:   stringParam(c.params, "label"),
+		Status:  "pending-time",
+	})
+
+	c.ok(geminix.AddressRequest{
+		Message: "Approved address addition is now waiting a 7-day approval hold before activation.",
+	})
+}
+
+func (c *call) approvedAddresses(arg string) {
+	addresses := []geminix.ApprovedAddress{}
+	for _, a := range c.account.approved[geminix.Network(arg)] {
+		addresses = append(addresses, geminix.ApprovedAddress{
+			Network: a.Network,
+			Scope:   "account",
+			Label:   a.Label,
+			Status:  a.Status,
+			Address: a.Address,
+		})
+	}
+
+	c.ok(geminix.ApprovedAddresses{ApprovedAddresses: addresses})
+}
+
+func (c *call) accountDetail(arg string) {
+	c.ok(geminix.AccountDetail{
+		Account: c.account.info,
+		Users: []geminix.User{{
+			Name:        "Test User",
+			Status:      "Active",
+			CountryCode: "US",
+			IsVerified:  "true",
+		}},
+	})
+}
+
+func (c *call) createAccount(arg string) {
+	name := stringParam(c.params, "name")
+	if name == "" {
+		c.fail(fail(http.StatusBadRequest, "MissingName", "Missing account name"))
+		return
+	}
+
+	Type := stringParam(c.params, "type")
+	if Type == "" {
+		Type = "exchange"
+	}
+
+	a := c.server.createAccount(name, "", Type)
+	c.ok(map[string]string{"account": a.info.Account, "type": a.info.Type})
+}
+
+func (c *call) accounts(arg string) {
+	accounts := make([]geminix.Account, 0, len(c.server.accounts))
+	for _, a := range c.server.accounts {
+		accounts = append(accounts, a.info)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Created < accounts[j].Created || (accounts[i].Created == accounts[j].Created && accounts[i].Account < accounts[j].Account)
+	})
+
+	c.ok(accounts)
+}
@@ -0,0 +1,519 @@
+// Package geminitest provides an in-process fake of the Gemini REST API for
+// testing code built on geminix without network access.
+package geminitest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	geminix "github.com/Haakam21/gemini-exchange-go"
+)
+
+type apiKey struct {
+	secret    string
+	account   string
+	master    bool
+	lastNonce int64
+}
+
+type account struct {
+	info             geminix.Account
+	balances         map[geminix.Currency]*balance
+	trades           []geminix.Trade
+	transfers        []geminix.Transfer
+	depositAddresses map[geminix.Network][]geminix.DepositAddress
+	approved         map[geminix.Network][]ApprovedAddress
+}
+
+type balance struct {
+	amount float64
+	held   float64
+}
+
+type ApprovedAddress struct {
+	Network geminix.Network
+	Address string
+	Label   string
+	Status  string
+}
+
+// Request is a private request received by the server, after authentication.
+type Request struct {
+	Key     string
+	Uri     string
+	Account string
+	Params  map[string]interface{}
+}
+
+type Server struct {
+	*httptest.Server
+
+	// Fees charged on fills, in basis points of the notional.
+	MakerFeeBps float64
+	TakerFeeBps float64
+
+	// Now is the clock used for timestamps. It defaults to time.Now.
+	Now func() time.Time
+
+	mutex     sync.Mutex
+	keys      map[string]*apiKey
+	accounts  map[string]*account
+	books     map[geminix.Symbol]*book
+	orders    map[uint64]*order
+	tickers   map[geminix.Symbol]geminix.Ticker
+	candles   map[string][]geminix.Candle
+	requests  []Request
+	nextId    uint64
+	nextTid   uint
+	nextEid   uint
+	accountNo int
+}
+
+func NewServer() *Server {
+	s := &Server{
+		MakerFeeBps: 10,
+		TakerFeeBps: 35,
+		Now:         time.Now,
+		keys:        map[string]*apiKey{},
+		accounts:    map[string]*account{},
+		books:       map[geminix.Symbol]*book{},
+		orders:      map[uint64]*order{},
+		tickers:     map[geminix.Symbol]geminix.Ticker{},
+		candles:     map[string][]geminix.Candle{},
+		nextId:      1000,
+		nextTid:     1,
+		nextEid:     1,
+	}
+
+	s.createAccount("Primary", "primary", "exchange")
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// AddKey registers an API key. Requests signed with a non-master key act on
+// the given account; master keys act on the account named in the request, or
+// on account if none is given.
+func (s *Server) AddKey(key string, secret string, account string, master bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.accounts[account]; !ok {
+		s.createAccount(account, account, "exchange")
+	}
+
+	s.keys[key] = &apiKey{secret: secret, account: account, master: master}
+}
+
+// Client returns a geminix client pointed at the server, using a key
+// previously registered with AddKey.
+func (s *Server) Client(key string) *geminix.Client {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	secret := ""
+	if k, ok := s.keys[key]; ok {
+		secret = k.secret
+	}
+
+	return geminix.NewClientWithUrl(s.URL, key, secret)
+}
+
+func (s *Server) CreateAccount(name string, Type string) geminix.Account {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.createAccount(name, "", Type).info
+}
+
+func (s *Server) createAccount(name string, shortName string, Type string) *account {
+	if shortName == "" {
+		shortName = strings.ToLower(strings.ReplaceAll(name, " ", "-"))
+	}
+
+	s.accountNo++
+
+	a := &account{
+		info: geminix.Account{
+			Name:           name,
+			AccountName:    name,
+			Account:        shortName,
+			ShortName:      shortName,
+			CounterpartyId: fmt.Sprintf("EMONNYXH%d", s.accountNo),
+			Type:           Type,
+			Created:        uint64(s.Now().UnixNano() / int64(time.Millisecond)),
+		},
+		balances:         map[geminix.Currency]*balance{},
+		depositAddresses: map[geminix.Network][]geminix.DepositAddress{},
+		approved:         map[geminix.Network][]ApprovedAddress{},
+	}
+	s.accounts[shortName] = a
+
+	return a
+}
+
+func (s *Server) account(name string) *account {
+	a, ok := s.accounts[name]
+	if !ok {
+		a = s.createAccount(name, name, "exchange")
+	}
+	return a
+}
+
+func (a *account) balance(currency geminix.Currency) *balance {
+	currency = geminix.Currency(strings.ToUpper(string(currency)))
+
+	b, ok := a.balances[currency]
+	if !ok {
+		b = &balance{}
+		a.balances[currency] = b
+	}
+	return b
+}
+
+// SetBalance sets the total balance of a currency in an account, without
+// recording a transfer.
+func (s *Server) SetBalance(account string, currency geminix.Currency, amount float64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.account(account).balance(currency).amount = amount
+}
+
+// Balance returns the total and available balance of a currency.
+func (s *Server) Balance(account string, currency geminix.Currency) (float64, float64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	b := s.account(account).balance(currency)
+	return b.amount, b.amount - b.held
+}
+
+// Deposit credits an account and records a completed deposit transfer.
+func (s *Server) Deposit(account string, currency geminix.Currency, amount float64) geminix.Transfer {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	a := s.account(account)
+	a.balance(currency).amount += amount
+
+	transfer := geminix.Transfer{
+		Type:        "Deposit",
+		Status:      "Complete",
+		Timestampms: s.nowms(),
+		EID:         s.eid(),
+		Currency:    currency,
+		Amount:      geminix.FormatAmount(amount),
+		Method:      "ACH",
+	}
+	a.transfers = append(a.transfers, transfer)
+
+	return transfer
+}
+
+func (s *Server) AddDepositAddress(account string, network geminix.Network, address string, label string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	a := s.account(account)
+	a.depositAddresses[network] = append(a.depositAddresses[network], geminix.DepositAddress{
+		Address:   address,
+		Timestamp: s.nowms(),
+		Label:     label,
+	})
+}
+
+// ApproveAddress marks a previously requested address as active, as if the
+// waiting period had elapsed.
+func (s *Server) ApproveAddress(account string, network geminix.Network, address string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	approved := s.account(account).approved[network]
+	for i := range approved {
+		if approved[i].Address == address {
+			approved[i].Status = "active"
+		}
+	}
+}
+
+// SetTicker overrides the ticker served for a symbol. Without an override the
+// ticker is derived from the order book and the last trade.
+func (s *Server) SetTicker(symbol geminix.Symbol, bid string, ask string, last string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.tickers[normalizeSymbol(symbol)] = geminix.Ticker{Bid: bid, Ask: ask, Last: last}
+}
+
+// SetCandles sets the candles served for a symbol and time frame.
+func (s *Server) SetCandles(symbol geminix.Symbol, timeFrame geminix.TimeFrame, candles []geminix.Candle) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.candles[string(normalizeSymbol(symbol))+"/"+string(timeFrame)] = append([]geminix.Candle(nil), candles...)
+}
+
+// Requests returns the authenticated private requests received so far.
+func (s *Server) Requests() []Request {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) nowms() uint64 {
+	return uint64(s.Now().UnixNano() / int64(time.Millisecond))
+}
+
+func (s *Server) eid() uint {
+	s.nextEid++
+	return s.nextEid
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, reason string, message string) {
+	writeJSON(w, status, map[string]string{
+		"result":  "error",
+		"reason":  reason,
+		"message": message,
+	})
+}
+
+type failure struct {
+	status  int
+	reason  string
+	message string
+}
+
+func fail(status int, reason string, message string) *failure {
+	return &failure{status: status, reason: reason, message: message}
+}
+
+// authenticate verifies the key, signature and nonce of a private request
+// and returns the decoded payload.
+func (s *Server) authenticate(r *http.Request) (*apiKey, string, map[string]interface{}, *failure) {
+	keyName := r.Header.Get("X-GEMINI-APIKEY")
+	payload := r.Header.Get("X-GEMINI-PAYLOAD")
+	signature := r.Header.Get("X-GEMINI-SIGNATURE")
+
+	if keyName == "" {
+		return nil, "", nil, fail(http.StatusBadRequest, "MissingApikeyHeader", "No API key header")
+	}
+	if payload == "" {
+		return nil, "", nil, fail(http.StatusBadRequest, "MissingPayloadHeader", "No payload header")
+	}
+	if signature == "" {
+		return nil, "", nil, fail(http.StatusBadRequest, "MissingSignatureHeader", "No signature header")
+	}
+
+	key, ok := s.keys[keyName]
+	if !ok {
+		return nil, "", nil, fail(http.StatusBadRequest, "InvalidSignature", "Unknown API key")
+	}
+
+	mac := hmac.New(sha512.New384, []byte(key.secret))
+	mac.Write([]byte(payload))
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, "", nil, fail(http.StatusBadRequest, "InvalidSignature", "InvalidSignature")
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, "", nil, fail(http.StatusBadRequest, "InvalidJson", "Payload is not base64")
+	}
+
+	var params map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(decoded))
+	decoder.UseNumber()
+	if err := decoder.Decode(&params); err != nil {
+		return nil, "", nil, fail(http.StatusBadRequest, "InvalidJson", "Payload is not valid JSON")
+	}
+
+	if request, _ := params["request"].(string); request != r.URL.Path {
+		return nil, "", nil, fail(http.StatusBadRequest, "EndpointMismatch", "Payload request does not match endpoint")
+	}
+
+	nonce, ok := int64Param(params, "nonce")
+	if !ok {
+		return nil, "", nil, fail(http.StatusBadRequest, "InvalidNonce", "Nonce is missing")
+	}
+	if nonce <= key.lastNonce {
+		return nil, "", nil, fail(http.StatusBadRequest, "InvalidNonce",
+			fmt.Sprintf("Nonce '%d' has not increased since your last call to the Gemini API.", nonce))
+	}
+	key.lastNonce = nonce
+
+	accountName := key.account
+	if name, ok := params["account"].(string); ok && name != "" {
+		if !key.master && name != key.account {
+			return nil, "", nil, fail(http.StatusForbidden, "InvalidAccount", "Account parameter requires a master key")
+		}
+		if _, ok := s.accounts[name]; !ok {
+			return nil, "", nil, fail(http.StatusBadRequest, "AccountNotFound", fmt.Sprintf("Account %s does not exist", name))
+		}
+		accountName = name
+	}
+
+	s.requests = append(s.requests, Request{Key: keyName, Uri: r.URL.Path, Account: accountName, Params: params})
+
+	return key, accountName, params, nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	path := r.URL.Path
+
+	if r.Method == http.MethodGet {
+		if symbol, ok := match(path, geminix.TickerUri); ok {
+			s.handleTicker(w, geminix.Symbol(symbol))
+			return
+		}
+		if symbol, ok := match(path, geminix.TradesUri); ok {
+			s.handleTrades(w, r, geminix.Symbol(symbol))
+			return
+		}
+		if symbol, timeFrame, ok := matchCandles(path); ok {
+			s.handleCandles(w, geminix.Symbol(symbol), geminix.TimeFrame(timeFrame))
+			return
+		}
+		if symbol, ok := match(path, geminix.OrderBookUri); ok {
+			s.handleOrderBook(w, r, geminix.Symbol(symbol))
+			return
+		}
+		writeError(w, http.StatusNotFound, "EndpointNotFound", fmt.Sprintf("API entry point `%s` not found", path))
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "InvalidMethod", "Method not allowed")
+		return
+	}
+
+	key, accountName, params, f := s.authenticate(r)
+	if f != nil {
+		writeError(w, f.status, f.reason, f.message)
+		return
+	}
+
+	c := &call{server: s, w: w, key: key, account: s.accounts[accountName], params: params}
+
+	for _, route := range routes {
+		if arg, ok := match(path, route.uri); ok {
+			if route.master && !key.master {
+				writeError(w, http.StatusForbidden, "MissingRole", "This endpoint requires a master API key")
+				return
+			}
+			route.handle(c, arg)
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "EndpointNotFound", fmt.Sprintf("API entry point `%s` not found", path))
+}
+
+// match reports whether path matches a URI constant, which may contain a
+// single %s placeholder, and returns the placeholder value.
+func match(path string, uri string) (string, bool) {
+	i := strings.Index(uri, "%s")
+	if i < 0 {
+		return "", path == uri
+	}
+
+	prefix, suffix := uri[:i], uri[i+2:]
+	if !strings.HasPrefix(path, prefix) || !strings.HasSuffix(path, suffix) || len(path) <= len(prefix)+len(suffix) {
+		return "", false
+	}
+
+	arg := path[len(prefix) : len(path)-len(suffix)]
+	if strings.Contains(arg, "/") {
+		return "", false
+	}
+
+	return arg, true
+}
+
+// matchCandles matches CandlesUri, the only URI with two placeholders.
+func matchCandles(path string) (string, string, bool) {
+	prefix := geminix.CandlesUri[:strings.Index(geminix.CandlesUri, "%s")]
+	if !strings.HasPrefix(path, prefix) {
+		return "", "", false
+	}
+
+	parts := strings.Split(strings.TrimPrefix(path, prefix), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
+
+func normalizeSymbol(symbol geminix.Symbol) geminix.Symbol {
+	return geminix.Symbol(strings.ToUpper(string(symbol)))
+}
+
+func stringParam(params map[string]interface{}, key string) string {
+	switch v := params[key].(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+	return ""
+}
+
+func int64Param(params map[string]interface{}, key string) (int64, bool) {
+	switch v := params[key].(type) {
+	case json.Number:
+		i, err := v.Int64()
+		return i, err == nil
+	case string:
+		n := json.Number(v)
+		i, err := n.Int64()
+		return i, err == nil
+	}
+	return 0, false
+}
+
+func uintParam(params map[string]interface{}, key string) (uint64, bool) {
+	i, ok := int64Param(params, key)
+	if !ok || i < 0 {
+		return 0, false
+	}
+	return uint64(i), true
+}
+
+func boolParam(params map[string]interface{}, key string) bool {
+	b, _ := params[key].(bool)
+	return b
+}
+
+func stringsParam(params map[string]interface{}, key string) []string {
+	values, _ := params[key].([]interface{})
+
+	var result []string
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
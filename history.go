@@ -0,0 +1,242 @@
+package geminix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrHistoryStalled is returned by the history iterators when a full page of
+// records shares one millisecond. The endpoints cannot be asked for anything
+// finer than a millisecond, so the records beyond that page cannot be
+// fetched, and the iterator stops rather than skip them.
+var ErrHistoryStalled = errors.New("geminix: a full page of records shares one millisecond")
+
+// historyCursor pages forward through records that can only be fetched from
+// a timestamp. It is shared by TradeIterator and TransferIterator.
+type historyCursor struct {
+	ctx    context.Context
+	limit  int
+	cursor uint64
+	seen   map[uint]bool
+	done   bool
+	err    error
+}
+
+// newHistoryCursor starts at since, or at the beginning for a zero or
+// pre-1970 since.
+func newHistoryCursor(ctx context.Context, since time.Time, limit int) historyCursor {
+	var cursor uint64
+	if ms := since.UnixMilli(); ms > 0 {
+		cursor = uint64(ms)
+	}
+
+	return historyCursor{
+		ctx:    ctx,
+		limit:  limit,
+		cursor: cursor,
+		seen:   map[uint]bool{},
+	}
+}
+
+// more reports whether another page should be fetched.
+func (h *historyCursor) more() bool {
+	if h.done || h.err != nil {
+		return false
+	}
+	if err := h.ctx.Err(); err != nil {
+		h.err = err
+		return false
+	}
+	return true
+}
+
+// fresh sorts a page of n records chronologically, by the timestamp and id
+// that key returns for the record at index i, and returns the indices of
+// those not returned by an earlier page. The cursor moves to the last of
+// them, remembering the ids at that timestamp, since the next page starts
+// there too.
+func (h *historyCursor) fresh(records interface{}, n int, key func(i int) (uint64, uint)) []int {
+	sort.Slice(records, func(i, j int) bool {
+		ti, idi := key(i)
+		tj, idj := key(j)
+		if ti != tj {
+			return ti < tj
+		}
+		return idi < idj
+	})
+
+	var fresh []int
+	for i := 0; i < n; i++ {
+		timestamp, id := key(i)
+		if timestamp < h.cursor || h.seen[id] {
+			continue
+		}
+		fresh = append(fresh, i)
+	}
+
+	if n < h.limit {
+		h.done = true
+	}
+
+	if len(fresh) == 0 {
+		if !h.done {
+			h.err = fmt.Errorf("%w: %d or more records at %d ms", ErrHistoryStalled, h.limit, h.cursor)
+		}
+		return nil
+	}
+
+	last, _ := key(fresh[len(fresh)-1])
+	if last > h.cursor {
+		h.cursor = last
+		h.seen = map[uint]bool{}
+	}
+	for _, i := range fresh {
+		if timestamp, id := key(i); timestamp == last {
+			h.seen[id] = true
+		}
+	}
+
+	return fresh
+}
+
+// TradeIterator walks the full trade history of a symbol forward in time,
+// starting at a given timestamp. The endpoint returns at most MaxLimitTrades
+// records per call, so the iterator advances a timestamp cursor and skips
+// records at the page boundary that were already returned.
+type TradeIterator struct {
+	historyCursor
+	exchange Exchange
+	symbol   Symbol
+	account  *string
+	page     []Trade
+	trade    Trade
+}
+
+// TradeHistory walks the trades of any Exchange, such as an OrderManager or
+// a PaperClient. ctx is checked between pages but not passed to the
+// exchange; Client.TradeHistory also cancels requests in flight.
+func TradeHistory(ctx context.Context, exchange Exchange, symbol Symbol, since time.Time, account *string) *TradeIterator {
+	return &TradeIterator{
+		historyCursor: newHistoryCursor(ctx, since, MaxLimitTrades),
+		exchange:      exchange,
+		symbol:        symbol,
+		account:       account,
+	}
+}
+
+func (c *Client) TradeHistory(ctx context.Context, symbol Symbol, since time.Time, account *string) *TradeIterator {
+	return TradeHistory(ctx, c.WithContext(ctx), symbol, since, account)
+}
+
+func (it *TradeIterator) Next() bool {
+	for len(it.page) == 0 {
+		if !it.more() {
+			return false
+		}
+		it.fetch()
+	}
+
+	it.trade = it.page[0]
+	it.page = it.page[1:]
+
+	return true
+}
+
+func (it *TradeIterator) Trade() Trade {
+	return it.trade
+}
+
+func (it *TradeIterator) Err() error {
+	return it.err
+}
+
+func (it *TradeIterator) All() ([]Trade, error) {
+	var trades []Trade
+	for it.Next() {
+		trades = append(trades, it.Trade())
+	}
+	return trades, it.Err()
+}
+
+func (it *TradeIterator) fetch() {
+	limit := uint(MaxLimitTrades)
+	timestamp := it.cursor
+
+	trades, err := it.exchange.PastTrades(it.symbol, &limit, &timestamp, it.account)
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	for _, i := range it.fresh(trades, len(trades), func(i int) (uint64, uint) { return trades[i].Timestampms, trades[i].Tid }) {
+		it.page = append(it.page, trades[i])
+	}
+}
+
+// TransferIterator walks the full transfer history forward in time in the
+// same way as TradeIterator, using MaxLimitTransfers records per call.
+type TransferIterator struct {
+	historyCursor
+	client            *Client
+	account           *string
+	completedAdvances *bool
+	page              []Transfer
+	transfer          Transfer
+}
+
+func (c *Client) TransferHistory(ctx context.Context, since time.Time, account *string, completedAdvances *bool) *TransferIterator {
+	return &TransferIterator{
+		historyCursor:     newHistoryCursor(ctx, since, MaxLimitTransfers),
+		client:            c.WithContext(ctx),
+		account:           account,
+		completedAdvances: completedAdvances,
+	}
+}
+
+func (it *TransferIterator) Next() bool {
+	for len(it.page) == 0 {
+		if !it.more() {
+			return false
+		}
+		it.fetch()
+	}
+
+	it.transfer = it.page[0]
+	it.page = it.page[1:]
+
+	return true
+}
+
+func (it *TransferIterator) Transfer() Transfer {
+	return it.transfer
+}
+
+func (it *TransferIterator) Err() error {
+	return it.err
+}
+
+func (it *TransferIterator) All() ([]Transfer, error) {
+	var transfers []Transfer
+	for it.Next() {
+		transfers = append(transfers, it.Transfer())
+	}
+	return transfers, it.Err()
+}
+
+func (it *TransferIterator) fetch() {
+	limit := uint(MaxLimitTransfers)
+	timestamp := it.cursor
+
+	transfers, err := it.client.Transfers(&timestamp, &limit, it.account, it.completedAdvances)
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	for _, i := range it.fresh(transfers, len(transfers), func(i int) (uint64, uint) { return transfers[i].Timestampms, transfers[i].EID }) {
+		it.page = append(it.page, transfers[i])
+	}
+}
@@ -0,0 +1,195 @@
+package geminix
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RequestInfo describes a request as it passes through the interceptors.
+// Params is a copy of the request parameters, which never include the API
+// key or signature; changes made by an interceptor are sent.
+type RequestInfo struct {
+	// Context is the context given to WithContext, or context.Background.
+	// An interceptor may replace it, for example with one carrying a span.
+	Context context.Context
+
+	Verb   string
+	Uri    string
+	Params map[string]interface{}
+}
+
+type ResponseInfo struct {
+	Status  int
+	Body    []byte
+	Latency time.Duration
+}
+
+// Handler sends a request. A response may accompany an error, as when the
+// exchange replies with an ApiError.
+type Handler func(req *RequestInfo) (*ResponseInfo, error)
+
+// Interceptor wraps a Handler. It may inspect or modify the request, call
+// next any number of times, or return without calling it at all.
+type Interceptor func(req *RequestInfo, next Handler) (*ResponseInfo, error)
+
+// Use adds interceptors around every request. The first interceptor added is
+// the outermost.
+func (c *Client) Use(interceptors ...Interceptor) {
+	c.interceptors.mutex.Lock()
+	defer c.interceptors.mutex.Unlock()
+
+	c.interceptors.list = append(c.interceptors.list, interceptors...)
+}
+
+type interceptors struct {
+	mutex sync.RWMutex
+	list  []Interceptor
+}
+
+func (i *interceptors) chain(handler Handler) Handler {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	for j := len(i.list) - 1; j >= 0; j-- {
+		interceptor, next := i.list[j], handler
+		handler = func(req *RequestInfo) (*ResponseInfo, error) {
+			return interceptor(req, next)
+		}
+	}
+	return handler
+}
+
+// Endpoint returns the URI constant a request path was built from, such as
+// TickerUri for /v1/pubticker/btcusd, or an empty string if none matches.
+// Templates keep cardinality low when labelling metrics or spans.
+func Endpoint(path string) string {
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+
+	for _, uri := range Uris {
+		if uri == path {
+			return uri
+		}
+	}
+
+	segments := strings.Split(path, "/")
+	for _, uri := range Uris {
+		templates := strings.Split(uri, "/")
+		if len(templates) != len(segments) || !strings.Contains(uri, "%s") {
+			continue
+		}
+
+		matched := true
+		for i, template := range templates {
+			if template != segments[i] && (template != "%s" || segments[i] == "") {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return uri
+		}
+	}
+
+	return ""
+}
+
+func copyParams(params map[string]interface{}) map[string]interface{} {
+	if params == nil {
+		return nil
+	}
+
+	copied := make(map[string]interface{}, len(params))
+	for key, value := range params {
+		copied[key] = value
+	}
+	return copied
+}
+
+// LogRequests logs the verb, uri, status, latency and error of every
+// request.
+func LogRequests(logger *log.Logger) Interceptor {
+	return func(req *RequestInfo, next Handler) (*ResponseInfo, error) {
+		res, err := next(req)
+
+		status := 0
+		var latency time.Duration
+		if res != nil {
+			status, latency = res.Status, res.Latency
+		}
+
+		if err != nil {
+			logger.Printf("%s %s %d %v: %v", req.Verb, req.Uri, status, latency, err)
+		} else {
+			logger.Printf("%s %s %d %v", req.Verb, req.Uri, status, latency)
+		}
+
+		return res, err
+	}
+}
+
+// RateLimit delays requests so that no more than perMinute are sent in any
+// minute, allowing bursts of up to burst requests. Gemini allows 120 public
+// and 600 private requests per minute. Both must be at least one.
+func RateLimit(perMinute int, burst int) Interceptor {
+	return RateLimitObserved(perMinute, burst, nil)
+}
+
+// RateLimitObserved is RateLimit, calling onWait with each delay it imposes.
+// A request whose context is done while it waits is not sent. It panics
+// unless perMinute and burst are both at least one.
+func RateLimitObserved(perMinute int, burst int, onWait func(wait time.Duration)) Interceptor {
+	if perMinute < 1 || burst < 1 {
+		panic(fmt.Sprintf("geminix: invalid rate limit of %d per minute with a burst of %d", perMinute, burst))
+	}
+
+	interval := time.Minute / time.Duration(perMinute)
+
+	var mutex sync.Mutex
+	tokens := float64(burst)
+	last := time.Now()
+
+	return func(req *RequestInfo, next Handler) (*ResponseInfo, error) {
+		mutex.Lock()
+		now := time.Now()
+		tokens += float64(now.Sub(last)) / float64(interval)
+		if tokens > float64(burst) {
+			tokens = float64(burst)
+		}
+		last = now
+
+		tokens--
+		wait := time.Duration(0)
+		if tokens < 0 {
+			wait = time.Duration(-tokens * float64(interval))
+		}
+		mutex.Unlock()
+
+		if wait > 0 {
+			if onWait != nil {
+				onWait(wait)
+			}
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-req.Context.Done():
+				timer.Stop()
+
+				// The request is not sent, so its token is returned.
+				mutex.Lock()
+				tokens++
+				mutex.Unlock()
+
+				return nil, req.Context.Err()
+			}
+		}
+
+		return next(req)
+	}
+}
@@ -0,0 +1,88 @@
+package geminix
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// StaticMarket serves tickers and order books set by the caller, e.g. from a
+// recording or a backtest.
+type StaticMarket struct {
+	mutex      sync.Mutex
+	tickers    map[Symbol]Ticker
+	orderBooks map[Symbol]OrderBook
+}
+
+func NewStaticMarket() *StaticMarket {
+	return &StaticMarket{tickers: map[Symbol]Ticker{}, orderBooks: map[Symbol]OrderBook{}}
+}
+
+func normalizeSymbol(symbol Symbol) Symbol {
+	return Symbol(strings.ToUpper(string(symbol)))
+}
+
+func (m *StaticMarket) SetTicker(symbol Symbol, ticker Ticker) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.tickers[normalizeSymbol(symbol)] = ticker
+}
+
+func (m *StaticMarket) SetOrderBook(symbol Symbol, orderBook OrderBook) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.orderBooks[normalizeSymbol(symbol)] = orderBook
+}
+
+func (m *StaticMarket) Ticker(symbol Symbol) (Ticker, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	ticker, ok := m.tickers[normalizeSymbol(symbol)]
+	if !ok {
+		return ticker, fmt.Errorf("geminix: no ticker for %v", symbol)
+	}
+
+	return ticker, nil
+}
+
+// OrderBook returns the order book set for the symbol, or a single level on
+// each side at the ticker's bid and ask if only a ticker was set.
+func (m *StaticMarket) OrderBook(symbol Symbol, limitBids *uint, limitAsks *uint) (OrderBook, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	symbol = normalizeSymbol(symbol)
+
+	orderBook, ok := m.orderBooks[symbol]
+	if !ok {
+		ticker, ok := m.tickers[symbol]
+		if !ok {
+			return orderBook, fmt.Errorf("geminix: no order book for %v", symbol)
+		}
+		return tickerBook(ticker), nil
+	}
+
+	if limitBids != nil && int(*limitBids) < len(orderBook.Bids) {
+		orderBook.Bids = orderBook.Bids[:*limitBids]
+	}
+	if limitAsks != nil && int(*limitAsks) < len(orderBook.Asks) {
+		orderBook.Asks = orderBook.Asks[:*limitAsks]
+	}
+
+	return orderBook, nil
+}
+
+// tickerBook builds a book with unlimited size at the ticker's bid and ask.
+func tickerBook(ticker Ticker) OrderBook {
+	var orderBook OrderBook
+	if ticker.Bid != "" {
+		orderBook.Bids = []BookEntry{{Price: ticker.Bid, Amount: "+Inf"}}
+	}
+	if ticker.Ask != "" {
+		orderBook.Asks = []BookEntry{{Price: ticker.Ask, Amount: "+Inf"}}
+	}
+	return orderBook
+}
@@ -0,0 +1,301 @@
+// Package metrics counts API usage of a geminix.Client and exposes it in the
+// Prometheus text format.
+//
+//	collector := metrics.NewCollector()
+//	client.Use(collector.Interceptor())
+//	client.Use(geminix.RateLimitObserved(600, 10, collector.ObserveRateLimitWait))
+//	http.Handle("/metrics", collector)
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	geminix "github.com/Haakam21/gemini-exchange-go"
+)
+
+// DefaultBuckets are the latency histogram bounds in seconds.
+var DefaultBuckets = []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Sample is a single value of a metric, for exporting to registries other
+// than Prometheus.
+type Sample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+type requestKey struct {
+	endpoint string
+	verb     string
+	status   string
+}
+
+type errorKey struct {
+	endpoint string
+	reason   string
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+type Collector struct {
+	buckets []float64
+
+	mutex          sync.Mutex
+	requests       map[requestKey]uint64
+	latencies      map[string]*histogram
+	errors         map[errorKey]uint64
+	rateLimitWaits uint64
+	rateLimitWait  time.Duration
+	reconnects     map[string]uint64
+}
+
+func NewCollector() *Collector {
+	return NewCollectorWithBuckets(DefaultBuckets)
+}
+
+func NewCollectorWithBuckets(buckets []float64) *Collector {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &Collector{
+		buckets:    buckets,
+		requests:   map[requestKey]uint64{},
+		latencies:  map[string]*histogram{},
+		errors:     map[errorKey]uint64{},
+		reconnects: map[string]uint64{},
+	}
+}
+
+// Interceptor records every request made through the client. Requests are
+// labelled with the URI constant they were built from, so that symbols and
+// currencies in the path do not create a series each.
+func (c *Collector) Interceptor() geminix.Interceptor {
+	return func(req *geminix.RequestInfo, next geminix.Handler) (*geminix.ResponseInfo, error) {
+		start := time.Now()
+		res, err := next(req)
+		c.observe(req, res, err, time.Since(start))
+		return res, err
+	}
+}
+
+func (c *Collector) observe(req *geminix.RequestInfo, res *geminix.ResponseInfo, err error, elapsed time.Duration) {
+	endpoint := geminix.Endpoint(req.Uri)
+	if endpoint == "" {
+		endpoint = "other"
+	}
+
+	status := "none"
+	latency := elapsed
+	if res != nil {
+		status = strconv.Itoa(res.Status)
+		latency = res.Latency
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.requests[requestKey{endpoint: endpoint, verb: req.Verb, status: status}]++
+
+	h, ok := c.latencies[endpoint]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(c.buckets))}
+		c.latencies[endpoint] = h
+	}
+	seconds := latency.Seconds()
+	for i, bound := range c.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+
+	if err != nil {
+		reason := "transport"
+		var apiErr *geminix.ApiError
+		if errors.As(err, &apiErr) {
+			reason = apiErr.Reason
+		}
+		c.errors[errorKey{endpoint: endpoint, reason: reason}]++
+	}
+}
+
+// ObserveRateLimitWait records a delay imposed by a rate limiter. It can be
+// passed to geminix.RateLimitObserved.
+func (c *Collector) ObserveRateLimitWait(wait time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.rateLimitWaits++
+	c.rateLimitWait += wait
+}
+
+// WebSocketReconnect records a reconnection of a WebSocket feed. It is a hook
+// for WebSocket clients, which this package does not provide.
+func (c *Collector) WebSocketReconnect(url string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.reconnects[url]++
+}
+
+// Samples returns the current value of every series, grouped by metric.
+func (c *Collector) Samples() []Sample {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var samples []Sample
+
+	requestKeys := make([]requestKey, 0, len(c.requests))
+	for key := range c.requests {
+		requestKeys = append(requestKeys, key)
+	}
+	sort.Slice(requestKeys, func(i, j int) bool {
+		a, b := requestKeys[i], requestKeys[j]
+		if a.endpoint != b.endpoint {
+			return a.endpoint < b.endpoint
+		}
+		if a.verb != b.verb {
+			return a.verb < b.verb
+		}
+		return a.status < b.status
+	})
+	for _, key := range requestKeys {
+		samples = append(samples, Sample{
+			Name:   "gemini_requests_total",
+			Labels: map[string]string{"endpoint": key.endpoint, "verb": key.verb, "status": key.status},
+			Value:  float64(c.requests[key]),
+		})
+	}
+
+	endpoints := make([]string, 0, len(c.latencies))
+	for endpoint := range c.latencies {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	for _, endpoint := range endpoints {
+		h := c.latencies[endpoint]
+		for i, bound := range c.buckets {
+			samples = append(samples, Sample{
+				Name:   "gemini_request_duration_seconds_bucket",
+				Labels: map[string]string{"endpoint": endpoint, "le": strconv.FormatFloat(bound, 'g', -1, 64)},
+				Value:  float64(h.counts[i]),
+			})
+		}
+		samples = append(samples,
+			Sample{Name: "gemini_request_duration_seconds_bucket", Labels: map[string]string{"endpoint": endpoint, "le": "+Inf"}, Value: float64(h.count)},
+			Sample{Name: "gemini_request_duration_seconds_sum", Labels: map[string]string{"endpoint": endpoint}, Value: h.sum},
+			Sample{Name: "gemini_request_duration_seconds_count", Labels: map[string]string{"endpoint": endpoint}, Value: float64(h.count)},
+		)
+	}
+
+	errorKeys := make([]errorKey, 0, len(c.errors))
+	for key := range c.errors {
+		errorKeys = append(errorKeys, key)
+	}
+	sort.Slice(errorKeys, func(i, j int) bool {
+		a, b := errorKeys[i], errorKeys[j]
+		if a.endpoint != b.endpoint {
+			return a.endpoint < b.endpoint
+		}
+		return a.reason < b.reason
+	})
+	for _, key := range errorKeys {
+		samples = append(samples, Sample{
+			Name:   "gemini_request_errors_total",
+			Labels: map[string]string{"endpoint": key.endpoint, "reason": key.reason},
+			Value:  float64(c.errors[key]),
+		})
+	}
+
+	samples = append(samples,
+		Sample{Name: "gemini_rate_limit_waits_total", Value: float64(c.rateLimitWaits)},
+		Sample{Name: "gemini_rate_limit_wait_seconds_total", Value: c.rateLimitWait.Seconds()},
+	)
+
+	urls := make([]string, 0, len(c.reconnects))
+	for url := range c.reconnects {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+	for _, url := range urls {
+		samples = append(samples, Sample{
+			Name:   "gemini_websocket_reconnects_total",
+			Labels: map[string]string{"url": url},
+			Value:  float64(c.reconnects[url]),
+		})
+	}
+
+	return samples
+}
+
+var help = []struct {
+	name string
+	kind string
+	help string
+}{
+	{"gemini_requests_total", "counter", "API requests by endpoint, verb and HTTP status."},
+	{"gemini_request_duration_seconds", "histogram", "API request latency by endpoint."},
+	{"gemini_request_errors_total", "counter", "API request errors by endpoint and reason."},
+	{"gemini_rate_limit_waits_total", "counter", "Requests delayed by the rate limiter."},
+	{"gemini_rate_limit_wait_seconds_total", "counter", "Total time requests were delayed by the rate limiter."},
+	{"gemini_websocket_reconnects_total", "counter", "WebSocket reconnections by URL."},
+}
+
+// WriteTo writes every series in the Prometheus text exposition format.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	samples := c.Samples()
+
+	var b strings.Builder
+	for _, metric := range help {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.kind)
+
+		for _, sample := range samples {
+			if sample.Name != metric.name && !strings.HasPrefix(sample.Name, metric.name+"_") {
+				continue
+			}
+			fmt.Fprintf(&b, "%s%s %s\n", sample.Name, formatLabels(sample.Labels), strconv.FormatFloat(sample.Value, 'g', -1, 64))
+		}
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// ServeHTTP serves the metrics, so that the collector can be mounted on
+// /metrics.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(w)
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + "=" + strconv.Quote(labels[name])
+	}
+
+	return "{" + strings.Join(parts, ",") + "}"
+}
@@ -0,0 +1,434 @@
+package geminix
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type OrderState string
+
+const (
+	OrderPending         OrderState = "pending"
+	OrderLive            OrderState = "live"
+	OrderPartiallyFilled OrderState = "partially filled"
+	OrderFilled          OrderState = "filled"
+	OrderCancelled       OrderState = "cancelled"
+	OrderRejected        OrderState = "rejected"
+)
+
+// Done reports whether the state is final.
+func (s OrderState) Done() bool {
+	return s == OrderFilled || s == OrderCancelled || s == OrderRejected
+}
+
+func (s OrderState) rank() int {
+	switch s {
+	case OrderPending:
+		return 0
+	case OrderLive:
+		return 1
+	case OrderPartiallyFilled:
+		return 2
+	}
+	return 3
+}
+
+// OrderStateOf derives the state of an order reported by the exchange. A
+// cancelled order keeps whatever it executed before the cancellation.
+func OrderStateOf(order Order) OrderState {
+	executed, _ := ParseAmount(order.ExecutedAmount)
+	remaining, _ := ParseAmount(order.RemainingAmount)
+
+	switch {
+	case order.IsCancelled:
+		return OrderCancelled
+	case order.IsLive && executed > 0:
+		return OrderPartiallyFilled
+	case order.IsLive:
+		return OrderLive
+	case executed > 0 && remaining <= 0:
+		return OrderFilled
+	}
+	return OrderPending
+}
+
+// ManagedOrder is the local record of an order.
+type ManagedOrder struct {
+	ClientOrderId *uint  `json:"client_order_id,omitempty"`
+	Symbol        Symbol `json:"symbol"`
+	Side          string `json:"side"`
+	Price         string `json:"price"`
+	Amount        string `json:"amount"`
+	Account       string `json:"account,omitempty"`
+
+	State OrderState `json:"state"`
+
+	// Order is the latest report from the exchange. Its OrderId is empty
+	// while the order is pending.
+	Order Order `json:"order"`
+
+	// Fills are the trades seen for the order, oldest first.
+	Fills []Trade `json:"fills,omitempty"`
+
+	// Err is the error that left the order pending or rejected it.
+	Err string `json:"error,omitempty"`
+
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+}
+
+func (m ManagedOrder) OrderId() string {
+	return m.Order.OrderId
+}
+
+func (m ManagedOrder) copy() ManagedOrder {
+	m.Fills = append([]Trade(nil), m.Fills...)
+	return m
+}
+
+type OrderTransition struct {
+	Order ManagedOrder
+	From  OrderState
+	To    OrderState
+}
+
+// OrderManager wraps an Exchange and keeps the state of every order placed
+// through it, updated by Poll or by order events passed to HandleUpdate.
+// Every other method is passed through to the wrapped exchange.
+type OrderManager struct {
+	Exchange
+
+	// Now is the clock used for Created and Updated. It defaults to time.Now.
+	Now func() time.Time
+
+	mutex       sync.Mutex
+	orders      []*ManagedOrder
+	byOrderId   map[string]*ManagedOrder
+	transitions []func(OrderTransition)
+}
+
+var _ Exchange = (*OrderManager)(nil)
+
+func NewOrderManager(exchange Exchange) *OrderManager {
+	return &OrderManager{
+		Exchange:  exchange,
+		Now:       time.Now,
+		byOrderId: map[string]*ManagedOrder{},
+	}
+}
+
+// OnTransition registers a callback for every change of state. Callbacks run
+// in the goroutine that observed the change, after the manager's lock is
+// released.
+func (m *OrderManager) OnTransition(callback func(OrderTransition)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.transitions = append(m.transitions, callback)
+}
+
+// NewOrder records the order as pending and places it. Orders the exchange
+// refuses, and orders it cancels before they execute or rest, such as
+// maker-or-cancel orders that would take, are rejected. If the request
+// fails for any other reason the order stays pending, since it may have
+// been placed; Poll finds it if it has a client order id.
+func (m *OrderManager) NewOrder(clientOrderId *uint, symbol Symbol, amount string, minAmount *string, price string, side string, Type string, options *[]string, stopPrice *string, account *string) (Order, error) {
+	managed := &ManagedOrder{
+		ClientOrderId: clientOrderId,
+		Symbol:        normalizeSymbol(symbol),
+		Side:          side,
+		Price:         price,
+		Amount:        amount,
+		State:         OrderPending,
+		Created:       m.Now(),
+	}
+	if account != nil {
+		managed.Account = *account
+	}
+	managed.Updated = managed.Created
+
+	m.mutex.Lock()
+	m.orders = append(m.orders, managed)
+	m.mutex.Unlock()
+
+	order, err := m.Exchange.NewOrder(clientOrderId, symbol, amount, minAmount, price, side, Type, options, stopPrice, account)
+
+	var transitions []OrderTransition
+
+	m.mutex.Lock()
+	var apiErr *ApiError
+	switch {
+	case errors.As(err, &apiErr):
+		managed.Err = err.Error()
+		transitions = m.setState(managed, OrderRejected)
+	case err != nil:
+		managed.Err = err.Error()
+	default:
+		executed, _ := ParseAmount(order.ExecutedAmount)
+		if order.IsCancelled && executed == 0 {
+			managed.Order = order
+			m.byOrderId[order.OrderId] = managed
+			managed.Err = order.Reason
+			transitions = m.setState(managed, OrderRejected)
+		} else {
+			transitions = m.apply(managed, order)
+		}
+	}
+	m.mutex.Unlock()
+
+	m.emit(transitions)
+
+	return order, err
+}
+
+// CancelOrder cancels the order and records the state it reports.
+func (m *OrderManager) CancelOrder(orderId uint, account *string) (Order, error) {
+	order, err := m.Exchange.CancelOrder(orderId, account)
+	if err != nil {
+		return order, err
+	}
+
+	m.HandleUpdate(order)
+
+	return order, nil
+}
+
+// HandleUpdate applies an order reported by the exchange, such as from an
+// order events feed. Updates older than the recorded state are ignored,
+// except for trades not seen before. Orders not placed through the manager
+// are recorded too. It returns the updated record.
+func (m *OrderManager) HandleUpdate(order Order) ManagedOrder {
+	return m.handleUpdate(order, "")
+}
+
+// handleUpdate is HandleUpdate, recording orders placed elsewhere as orders
+// of account.
+func (m *OrderManager) handleUpdate(order Order, account string) ManagedOrder {
+	m.mutex.Lock()
+	managed, ok := m.byOrderId[order.OrderId]
+	if !ok {
+		managed = m.find(order, account)
+	}
+	transitions := m.apply(managed, order)
+	result := managed.copy()
+	m.mutex.Unlock()
+
+	m.emit(transitions)
+
+	return result
+}
+
+// find returns the pending order with the order's client order id, or a new
+// record of account for an order placed elsewhere.
+func (m *OrderManager) find(order Order, account string) *ManagedOrder {
+	if order.ClientOrderId != "" {
+		for _, managed := range m.orders {
+			if managed.Order.OrderId == "" && managed.ClientOrderId != nil &&
+				strconv.FormatUint(uint64(*managed.ClientOrderId), 10) == order.ClientOrderId {
+				return managed
+			}
+		}
+	}
+
+	managed := &ManagedOrder{
+		Symbol:  normalizeSymbol(Symbol(order.Symbol)),
+		Side:    order.Side,
+		Price:   order.Price,
+		Amount:  order.OriginalAmount,
+		Account: account,
+		State:   OrderPending,
+		Created: m.Now(),
+	}
+	if id, err := strconv.ParseUint(order.ClientOrderId, 10, 64); err == nil {
+		clientOrderId := uint(id)
+		managed.ClientOrderId = &clientOrderId
+	}
+	managed.Updated = managed.Created
+	m.orders = append(m.orders, managed)
+
+	return managed
+}
+
+func (m *OrderManager) apply(managed *ManagedOrder, order Order) []OrderTransition {
+	if order.OrderId != "" {
+		m.byOrderId[order.OrderId] = managed
+	}
+
+	seen := map[uint]bool{}
+	for _, fill := range managed.Fills {
+		seen[fill.Tid] = true
+	}
+	for _, trade := range order.Trades {
+		if !seen[trade.Tid] {
+			seen[trade.Tid] = true
+			managed.Fills = append(managed.Fills, trade)
+			managed.Updated = m.Now()
+		}
+	}
+
+	if managed.State.Done() {
+		return nil
+	}
+
+	state := OrderStateOf(order)
+	executed, _ := ParseAmount(order.ExecutedAmount)
+	recorded, _ := ParseAmount(managed.Order.ExecutedAmount)
+	if state.rank() < managed.State.rank() || executed < recorded {
+		return nil
+	}
+
+	trades := managed.Order.Trades
+	managed.Order = order
+	if order.Trades == nil {
+		managed.Order.Trades = trades
+	}
+	managed.Updated = m.Now()
+
+	return m.setState(managed, state)
+}
+
+func (m *OrderManager) setState(managed *ManagedOrder, state OrderState) []OrderTransition {
+	if managed.State == state {
+		return nil
+	}
+
+	from := managed.State
+	managed.State = state
+	managed.Updated = m.Now()
+
+	return []OrderTransition{{Order: managed.copy(), From: from, To: state}}
+}
+
+func (m *OrderManager) emit(transitions []OrderTransition) {
+	if len(transitions) == 0 {
+		return
+	}
+
+	m.mutex.Lock()
+	callbacks := make([]func(OrderTransition), len(m.transitions))
+	copy(callbacks, m.transitions)
+	m.mutex.Unlock()
+
+	for _, transition := range transitions {
+		for _, callback := range callbacks {
+			callback(transition)
+		}
+	}
+}
+
+// Poll fetches the status of every order that is not done, including its
+// trades. It continues past errors and returns the first.
+func (m *OrderManager) Poll() error {
+	m.mutex.Lock()
+	var open []ManagedOrder
+	for _, managed := range m.orders {
+		if !managed.State.Done() {
+			open = append(open, *managed)
+		}
+	}
+	m.mutex.Unlock()
+
+	var first error
+	for _, managed := range open {
+		order, err := m.status(managed)
+		if err == errNoOrderId {
+			continue
+		}
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+
+		m.HandleUpdate(order)
+	}
+
+	return first
+}
+
+var errNoOrderId = errors.New("geminix: order has neither an order id nor a client order id")
+
+// status fetches an order with its trades by order id, or by client order id
+// while it is pending.
+func (m *OrderManager) status(managed ManagedOrder) (Order, error) {
+	var account *string
+	if managed.Account != "" {
+		account = &managed.Account
+	}
+
+	var orderId uint
+	if managed.Order.OrderId != "" {
+		id, err := strconv.ParseUint(managed.Order.OrderId, 10, 64)
+		if err != nil {
+			return Order{}, err
+		}
+		orderId = uint(id)
+	} else if managed.ClientOrderId == nil {
+		return Order{}, errNoOrderId
+	}
+
+	includeTrades := true
+	return m.Exchange.OrderStatus(orderId, managed.ClientOrderId, &includeTrades, account)
+}
+
+// Order returns the record of the order with the exchange's order id.
+func (m *OrderManager) Order(orderId string) (ManagedOrder, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	managed, ok := m.byOrderId[orderId]
+	if !ok {
+		return ManagedOrder{}, false
+	}
+	return managed.copy(), true
+}
+
+// Orders returns every recorded order in the order they were placed or
+// first seen.
+func (m *OrderManager) Orders() []ManagedOrder {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	orders := make([]ManagedOrder, len(m.orders))
+	for i, managed := range m.orders {
+		orders[i] = managed.copy()
+	}
+	return orders
+}
+
+// OpenOrders returns the orders on a symbol that are not done, or on every
+// symbol if symbol is empty.
+func (m *OrderManager) OpenOrders(symbol Symbol) []ManagedOrder {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	symbol = normalizeSymbol(symbol)
+
+	var orders []ManagedOrder
+	for _, managed := range m.orders {
+		if !managed.State.Done() && (symbol == "" || managed.Symbol == symbol) {
+			orders = append(orders, managed.copy())
+		}
+	}
+	return orders
+}
+
+// Fills returns the trades of every order on a symbol, or on every symbol if
+// symbol is empty.
+func (m *OrderManager) Fills(symbol Symbol) []Trade {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	symbol = normalizeSymbol(symbol)
+
+	var fills []Trade
+	for _, managed := range m.orders {
+		if symbol == "" || managed.Symbol == symbol {
+			fills = append(fills, managed.Fills...)
+		}
+	}
+	return fills
+}
@@ -0,0 +1,106 @@
+package geminix
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// OrderStore persists the records of an OrderManager between runs.
+type OrderStore interface {
+	// Load returns the stored orders. An empty store returns no orders and
+	// no error.
+	Load() ([]ManagedOrder, error)
+	Save(orders []ManagedOrder) error
+}
+
+// FileOrderStore keeps orders in a JSON file.
+type FileOrderStore struct {
+	path string
+}
+
+var _ OrderStore = (*FileOrderStore)(nil)
+
+func NewFileOrderStore(path string) *FileOrderStore {
+	return &FileOrderStore{path: path}
+}
+
+func (s *FileOrderStore) Load() ([]ManagedOrder, error) {
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []ManagedOrder
+	err = json.Unmarshal(data, &orders)
+	return orders, err
+}
+
+// Save replaces the stored orders. The file is written to a temporary path
+// and renamed so that a crash never leaves a partial file.
+func (s *FileOrderStore) Save(orders []ManagedOrder) error {
+	data, err := json.MarshalIndent(orders, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.path)
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := ioutil.TempFile(dir, ".orders-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(data)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), s.path)
+}
+
+// Restore replaces the manager's records with the orders in the store. It is
+// meant to be called once, before any orders are placed; Reconcile then
+// brings the records up to date with the exchange.
+func (m *OrderManager) Restore(store OrderStore) error {
+	orders, err := store.Load()
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.orders = make([]*ManagedOrder, len(orders))
+	m.byOrderId = map[string]*ManagedOrder{}
+	for i := range orders {
+		managed := orders[i]
+		m.orders[i] = &managed
+		if managed.Order.OrderId != "" {
+			m.byOrderId[managed.Order.OrderId] = &managed
+		}
+	}
+
+	return nil
+}
+
+// Save writes every record to the store. To keep the store current, call it
+// from a transition callback:
+//
+//	manager.OnTransition(func(OrderTransition) { manager.Save(store) })
+func (m *OrderManager) Save(store OrderStore) error {
+	return store.Save(m.Orders())
+}
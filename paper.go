@@ -0,0 +1,562 @@
+package geminix
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type PaperConfig struct {
+	// Fees charged on fills, in basis points of the notional.
+	MakerFeeBps float64
+	TakerFeeBps float64
+
+	// Latency is added before every order placement and cancellation.
+	Latency time.Duration
+
+	// Balances is the starting balance of each currency.
+	Balances map[Currency]float64
+}
+
+type paperBalance struct {
+	amount float64
+	held   float64
+}
+
+type paperOrder struct {
+	id       uint
+	order    Order
+	symbol   Symbol
+	price    float64
+	original float64
+	executed float64
+	notional float64
+}
+
+func (o *paperOrder) remaining() float64 {
+	return o.original - o.executed
+}
+
+// PaperClient simulates order placement against live or recorded market
+// data. Aggressive orders fill immediately against the order book at taker
+// fees; resting orders fill in full at their limit price at maker fees once
+// the ticker trades through them. The account parameter is ignored: a paper
+// client holds a single set of balances.
+type PaperClient struct {
+	market MarketData
+	config PaperConfig
+
+	// Now is the clock used for timestamps. It defaults to time.Now.
+	Now func() time.Time
+
+	mutex    sync.Mutex
+	balances map[Currency]*paperBalance
+	orders   map[uint]*paperOrder
+	trades   []Trade
+	nextId   uint
+	nextTid  uint
+}
+
+func NewPaperClient(market MarketData, config PaperConfig) *PaperClient {
+	p := &PaperClient{
+		market:   market,
+		config:   config,
+		Now:      time.Now,
+		balances: map[Currency]*paperBalance{},
+		orders:   map[uint]*paperOrder{},
+	}
+
+	for currency, amount := range config.Balances {
+		p.balance(currency).amount = amount
+	}
+
+	return p
+}
+
+func (p *PaperClient) balance(currency Currency) *paperBalance {
+	currency = Currency(strings.ToUpper(string(currency)))
+
+	b, ok := p.balances[currency]
+	if !ok {
+		b = &paperBalance{}
+		p.balances[currency] = b
+	}
+	return b
+}
+
+func (p *PaperClient) nowms() uint64 {
+	return uint64(p.Now().UnixNano() / int64(time.Millisecond))
+}
+
+func (p *PaperClient) Ticker(symbol Symbol) (Ticker, error) {
+	return p.market.Ticker(symbol)
+}
+
+func (p *PaperClient) OrderBook(symbol Symbol, limitBids *uint, limitAsks *uint) (OrderBook, error) {
+	return p.market.OrderBook(symbol, limitBids, limitAsks)
+}
+
+func paperError(reason string, message string) error {
+	return &ApiError{Reason: reason, Message: message}
+}
+
+func hasOption(options *[]string, option string) bool {
+	if options == nil {
+		return false
+	}
+	for _, o := range *options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *PaperClient) NewOrder(clientOrderId *uint, symbol Symbol, amount string, minAmount *string, price string, side string, Type string, options *[]string, stopPrice *string, account *string) (Order, error) {
+	time.Sleep(p.config.Latency)
+
+	if Type != ExchangeLimit {
+		return Order{}, paperError("InvalidOrderType", "Unsupported order type "+Type)
+	}
+	if side != Buy && side != Sell {
+		return Order{}, paperError("InvalidSide", "Invalid side "+side)
+	}
+
+	original, err := ParseAmount(amount)
+	if err != nil || original <= 0 {
+		return Order{}, paperError("InvalidQuantity", "Invalid quantity "+amount)
+	}
+
+	limit, err := ParseAmount(price)
+	if err != nil || limit <= 0 {
+		return Order{}, paperError("InvalidPrice", "Invalid price "+price)
+	}
+
+	symbol = normalizeSymbol(symbol)
+	base, quote := symbol.Split()
+	if base == "" {
+		return Order{}, paperError("InvalidSymbol", "Invalid symbol "+string(symbol))
+	}
+
+	orderBook, err := p.market.OrderBook(symbol, nil, nil)
+	if err != nil {
+		return Order{}, err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if side == Buy {
+		if b := p.balance(quote); b.amount-b.held < limit*original*(1+p.config.TakerFeeBps/10000) {
+			return Order{}, paperError("InsufficientFunds", "Insufficient funds")
+		}
+	} else {
+		if b := p.balance(base); b.amount-b.held < original {
+			return Order{}, paperError("InsufficientFunds", "Insufficient funds")
+		}
+	}
+
+	p.nextId++
+
+	o := &paperOrder{
+		order: Order{
+			OrderId:     strconv.FormatUint(uint64(p.nextId), 10),
+			Symbol:      strings.ToLower(string(symbol)),
+			Exchange:    "gemini",
+			Side:        side,
+			Type:        Type,
+			Options:     []string{},
+			Timestampms: p.nowms(),
+		},
+		id:       p.nextId,
+		symbol:   symbol,
+		price:    limit,
+		original: original,
+	}
+	o.order.Timestamp = strconv.FormatUint(o.order.Timestampms/1000, 10)
+	if clientOrderId != nil {
+		o.order.ClientOrderId = strconv.FormatUint(uint64(*clientOrderId), 10)
+	}
+	if options != nil {
+		o.order.Options = append(o.order.Options, *options...)
+	}
+	p.orders[p.nextId] = o
+
+	levels := orderBook.Asks
+	crosses := func(levelPrice float64) bool { return levelPrice <= limit }
+	if side == Sell {
+		levels = orderBook.Bids
+		crosses = func(levelPrice float64) bool { return levelPrice >= limit }
+	}
+
+	var available float64
+	for _, level := range levels {
+		levelPrice, _ := ParseAmount(level.Price)
+		if !crosses(levelPrice) {
+			break
+		}
+		levelAmount, _ := ParseAmount(level.Amount)
+		available += levelAmount
+	}
+
+	switch {
+	case hasOption(options, MakerOrCancel) && available > 0:
+		o.order.IsCancelled = true
+		o.order.Reason = "MakerOrCancelWouldTake"
+	case hasOption(options, FillOrKill) && available < original:
+		o.order.IsCancelled = true
+		o.order.Reason = "FillOrKillWouldNotFill"
+	default:
+		for _, level := range levels {
+			if o.remaining() <= 0 {
+				break
+			}
+
+			levelPrice, _ := ParseAmount(level.Price)
+			if !crosses(levelPrice) {
+				break
+			}
+
+			levelAmount, _ := ParseAmount(level.Amount)
+			fill := o.remaining()
+			if levelAmount < fill {
+				fill = levelAmount
+			}
+
+			p.fill(o, fill, levelPrice, false)
+		}
+
+		if o.remaining() > 0 {
+			if hasOption(options, ImmediateOrCancel) {
+				o.order.IsCancelled = true
+				o.order.Reason = "ImmediateOrCancelWouldPost"
+			} else {
+				p.hold(o, 1)
+				o.order.IsLive = true
+			}
+		}
+	}
+
+	o.refresh()
+
+	return o.order, nil
+}
+
+// hold reserves (sign 1) or releases (sign -1) the funds for the remaining
+// amount of a resting order.
+func (p *PaperClient) hold(o *paperOrder, sign float64) {
+	base, quote := o.symbol.Split()
+	if o.order.Side == Buy {
+		p.balance(quote).held += sign * o.remaining() * o.price
+	} else {
+		p.balance(base).held += sign * o.remaining()
+	}
+}
+
+func (p *PaperClient) fill(o *paperOrder, amount float64, price float64, isMaker bool) {
+	base, quote := o.symbol.Split()
+
+	feeBps := p.config.TakerFeeBps
+	if isMaker {
+		feeBps = p.config.MakerFeeBps
+	}
+
+	notional := amount * price
+	fee := notional * feeBps / 10000
+
+	if o.order.Side == Buy {
+		p.balance(base).amount += amount
+		p.balance(quote).amount -= notional + fee
+	} else {
+		p.balance(base).amount -= amount
+		p.balance(quote).amount += notional - fee
+	}
+
+	o.executed += amount
+	o.notional += notional
+
+	now := p.Now()
+	p.nextTid++
+
+	tradeType := "Buy"
+	if o.order.Side == Sell {
+		tradeType = "Sell"
+	}
+
+	trade := Trade{
+		Symbol:        o.order.Symbol,
+		Price:         FormatAmount(price),
+		Amount:        FormatAmount(amount),
+		Timestamp:     uint64(now.Unix()),
+		Timestampms:   uint64(now.UnixNano() / int64(time.Millisecond)),
+		Type:          tradeType,
+		Aggressor:     !isMaker,
+		FeeCurrency:   string(quote),
+		FeeAmount:     FormatAmount(fee),
+		Tid:           p.nextTid,
+		OrderId:       o.order.OrderId,
+		ClientOrderId: o.order.ClientOrderId,
+		Exchange:      "gemini",
+	}
+
+	o.order.Trades = append(o.order.Trades, trade)
+	p.trades = append(p.trades, trade)
+}
+
+func (o *paperOrder) refresh() {
+	o.order.Price = FormatAmount(o.price)
+	o.order.OriginalAmount = FormatAmount(o.original)
+	o.order.ExecutedAmount = FormatAmount(o.executed)
+	o.order.RemainingAmount = FormatAmount(o.remaining())
+	o.order.AvgExecutionPrice = "0"
+	if o.executed > 0 {
+		o.order.AvgExecutionPrice = FormatAmount(o.notional / o.executed)
+	}
+	if o.remaining() <= 0 {
+		o.order.IsLive = false
+	}
+}
+
+func (o *paperOrder) view(includeTrades bool) Order {
+	order := o.order
+	order.Options = append([]string{}, o.order.Options...)
+	order.Trades = nil
+	if includeTrades {
+		order.Trades = append([]Trade{}, o.order.Trades...)
+	}
+	return order
+}
+
+// Sync fills resting orders that the current ticker has traded through. It
+// is called before every read, so callers only need it to drive fills
+// explicitly, e.g. in a backtest.
+func (p *PaperClient) Sync() error {
+	p.mutex.Lock()
+	symbols := map[Symbol]bool{}
+	for _, o := range p.orders {
+		if o.order.IsLive {
+			symbols[o.symbol] = true
+		}
+	}
+	p.mutex.Unlock()
+
+	for symbol := range symbols {
+		ticker, err := p.market.Ticker(symbol)
+		if err != nil {
+			return err
+		}
+
+		bid, _ := ParseAmount(ticker.Bid)
+		ask, _ := ParseAmount(ticker.Ask)
+
+		p.mutex.Lock()
+		for _, o := range p.sortedOrders() {
+			if !o.order.IsLive || o.symbol != symbol {
+				continue
+			}
+
+			if (o.order.Side == Buy && ask > 0 && ask <= o.price) || (o.order.Side == Sell && bid > 0 && bid >= o.price) {
+				p.hold(o, -1)
+				p.fill(o, o.remaining(), o.price, true)
+				o.refresh()
+			}
+		}
+		p.mutex.Unlock()
+	}
+
+	return nil
+}
+
+func (p *PaperClient) sortedOrders() []*paperOrder {
+	orders := make([]*paperOrder, 0, len(p.orders))
+	for _, o := range p.orders {
+		orders = append(orders, o)
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].id < orders[j].id })
+	return orders
+}
+
+func (p *PaperClient) CancelOrder(orderId uint, account *string) (Order, error) {
+	time.Sleep(p.config.Latency)
+
+	err := p.Sync()
+	if err != nil {
+		return Order{}, err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	o, ok := p.orders[orderId]
+	if !ok {
+		return Order{}, paperError("OrderNotFound", fmt.Sprintf("Order %d not found", orderId))
+	}
+
+	if o.order.IsLive {
+		p.hold(o, -1)
+		o.order.IsLive = false
+		o.order.IsCancelled = true
+		o.order.Reason = "Requested"
+	}
+
+	return o.view(false), nil
+}
+
+func (p *PaperClient) CancelAll(account *string) (CancelResult, error) {
+	time.Sleep(p.config.Latency)
+
+	err := p.Sync()
+	if err != nil {
+		return CancelResult{}, err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	result := CancelResult{
+		Result: "ok",
+		Details: CancelResultDetails{
+			CancelledOrders: []uint64{},
+			CancelRejects:   []uint64{},
+		},
+	}
+
+	for _, o := range p.sortedOrders() {
+		if o.order.IsLive {
+			p.hold(o, -1)
+			o.order.IsLive = false
+			o.order.IsCancelled = true
+			o.order.Reason = "Requested"
+			result.Details.CancelledOrders = append(result.Details.CancelledOrders, uint64(o.id))
+		}
+	}
+
+	return result, nil
+}
+
+func (p *PaperClient) OrderStatus(orderId uint, clientOrderId *uint, includeTrades *bool, account *string) (Order, error) {
+	err := p.Sync()
+	if err != nil {
+		return Order{}, err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	o, ok := p.orders[orderId]
+	if !ok && clientOrderId != nil {
+		id := strconv.FormatUint(uint64(*clientOrderId), 10)
+		for _, candidate := range p.sortedOrders() {
+			if candidate.order.ClientOrderId == id {
+				o, ok = candidate, true
+			}
+		}
+	}
+	if !ok {
+		return Order{}, paperError("OrderNotFound", fmt.Sprintf("Order %d not found", orderId))
+	}
+
+	return o.view(includeTrades != nil && *includeTrades), nil
+}
+
+func (p *PaperClient) ActiveOrders(account *string) ([]Order, error) {
+	err := p.Sync()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	orders := []Order{}
+	for _, o := range p.sortedOrders() {
+		if o.order.IsLive {
+			orders = append(orders, o.view(false))
+		}
+	}
+
+	return orders, nil
+}
+
+func (p *PaperClient) PastTrades(symbol Symbol, limitTrades *uint, timestamp *uint64, account *string) ([]Trade, error) {
+	err := p.Sync()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	limit := 50
+	if limitTrades != nil {
+		limit = int(*limitTrades)
+	}
+
+	var since uint64
+	if timestamp != nil {
+		since = *timestamp
+		if since < 100000000000 {
+			since *= 1000
+		}
+	}
+
+	symbolName := strings.ToLower(string(symbol))
+
+	var matching []Trade
+	for _, trade := range p.trades {
+		if trade.Symbol == symbolName && trade.Timestampms >= since {
+			matching = append(matching, trade)
+		}
+	}
+
+	// Like the exchange, a timestamp selects the oldest trades after it, so
+	// that history can be paged forward, and no timestamp the newest. Either
+	// way they are returned newest first.
+	if len(matching) > limit {
+		if timestamp != nil {
+			matching = matching[:limit]
+		} else {
+			matching = matching[len(matching)-limit:]
+		}
+	}
+
+	trades := make([]Trade, 0, len(matching))
+	for i := len(matching) - 1; i >= 0; i-- {
+		trades = append(trades, matching[i])
+	}
+
+	return trades, nil
+}
+
+func (p *PaperClient) Balances(account *string) ([]Balance, error) {
+	err := p.Sync()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	currencies := make([]Currency, 0, len(p.balances))
+	for currency := range p.balances {
+		currencies = append(currencies, currency)
+	}
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i] < currencies[j] })
+
+	balances := []Balance{}
+	for _, currency := range currencies {
+		b := p.balances[currency]
+		balances = append(balances, Balance{
+			Currency:               currency,
+			Amount:                 FormatAmount(b.amount),
+			Available:              FormatAmount(b.amount - b.held),
+			AvailableForWithdrawal: FormatAmount(b.amount - b.held),
+			Type:                   "exchange",
+		})
+	}
+
+	return balances, nil
+}
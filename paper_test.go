@@ -0,0 +1,40 @@
+package geminix
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPaperTradeHistoryPages(t *testing.T) {
+	p := NewPaperClient(NewStaticMarket(), PaperConfig{})
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	const count = 2*MaxLimitTrades + 100
+	for i := 0; i < count; i++ {
+		at := start.Add(time.Duration(i) * time.Second)
+		p.trades = append(p.trades, Trade{
+			Symbol:      "btcusd",
+			Price:       "100",
+			Amount:      "1",
+			Timestampms: uint64(at.UnixNano() / int64(time.Millisecond)),
+			Tid:         uint(i + 1),
+		})
+	}
+
+	// A zero since starts at the beginning.
+	for _, since := range []time.Time{start, {}} {
+		trades, err := TradeHistory(context.Background(), p, "btcusd", since, nil).All()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trades) != count {
+			t.Fatalf("since %v: got %d trades, want %d", since, len(trades), count)
+		}
+		for i, trade := range trades {
+			if trade.Tid != uint(i+1) {
+				t.Fatalf("since %v: trade %d has tid %d, want %d", since, i, trade.Tid, i+1)
+			}
+		}
+	}
+}
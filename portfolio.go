@@ -0,0 +1,210 @@
+package geminix
+
+import (
+	"sort"
+	"time"
+)
+
+type Holding struct {
+	Currency               Currency
+	Amount                 float64
+	Available              float64
+	AvailableForWithdrawal float64
+	HeldInOrders           float64
+	Notional               float64
+	AvailableNotional      float64
+}
+
+func (h Holding) Add(o Holding) Holding {
+	return Holding{
+		Currency:               h.Currency,
+		Amount:                 h.Amount + o.Amount,
+		Available:              h.Available + o.Available,
+		AvailableForWithdrawal: h.AvailableForWithdrawal + o.AvailableForWithdrawal,
+		HeldInOrders:           h.HeldInOrders + o.HeldInOrders,
+		Notional:               h.Notional + o.Notional,
+		AvailableNotional:      h.AvailableNotional + o.AvailableNotional,
+	}
+}
+
+func (h Holding) Sub(o Holding) Holding {
+	return Holding{
+		Currency:               h.Currency,
+		Amount:                 h.Amount - o.Amount,
+		Available:              h.Available - o.Available,
+		AvailableForWithdrawal: h.AvailableForWithdrawal - o.AvailableForWithdrawal,
+		HeldInOrders:           h.HeldInOrders - o.HeldInOrders,
+		Notional:               h.Notional - o.Notional,
+		AvailableNotional:      h.AvailableNotional - o.AvailableNotional,
+	}
+}
+
+func (h Holding) IsZero() bool {
+	return h.Amount == 0 && h.Available == 0 && h.AvailableForWithdrawal == 0 && h.HeldInOrders == 0 && h.Notional == 0 && h.AvailableNotional == 0
+}
+
+func NewHolding(balance Balance) (Holding, error) {
+	holding := Holding{Currency: balance.Currency}
+
+	fields := []struct {
+		dst *float64
+		src string
+	}{
+		{&holding.Amount, balance.Amount},
+		{&holding.Available, balance.Available},
+		{&holding.AvailableForWithdrawal, balance.AvailableForWithdrawal},
+		{&holding.Notional, balance.AmountNotional},
+		{&holding.AvailableNotional, balance.AvailableNotional},
+	}
+
+	for _, field := range fields {
+		value, err := ParseAmount(field.src)
+		if err != nil {
+			return holding, err
+		}
+		*field.dst = value
+	}
+
+	holding.HeldInOrders = holding.Amount - holding.Available
+
+	return holding, nil
+}
+
+type Holdings map[Currency]Holding
+
+func (h Holdings) add(holding Holding) {
+	existing, ok := h[holding.Currency]
+	if !ok {
+		existing = Holding{Currency: holding.Currency}
+	}
+	h[holding.Currency] = existing.Add(holding)
+}
+
+func (h Holdings) Currencies() []Currency {
+	currencies := make([]Currency, 0, len(h))
+	for currency := range h {
+		currencies = append(currencies, currency)
+	}
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i] < currencies[j] })
+	return currencies
+}
+
+func (h Holdings) Notional() float64 {
+	var total float64
+	for _, holding := range h {
+		total += holding.Notional
+	}
+	return total
+}
+
+func (h Holdings) Sub(o Holdings) Holdings {
+	diff := Holdings{}
+
+	for currency, holding := range h {
+		delta := holding.Sub(o[currency])
+		if !delta.IsZero() {
+			diff[currency] = delta
+		}
+	}
+
+	for currency, holding := range o {
+		if _, ok := h[currency]; !ok {
+			diff[currency] = Holding{Currency: currency}.Sub(holding)
+		}
+	}
+
+	return diff
+}
+
+type Portfolio struct {
+	Fiat      Currency
+	Timestamp time.Time
+	Accounts  map[string]Holdings
+	Totals    Holdings
+}
+
+// Portfolio takes a snapshot of the holdings of every account visible to the
+// API key, valued in the given fiat currency.
+func (c *Client) Portfolio(fiat Currency) (Portfolio, error) {
+	balances, err := c.AllNotionalBalances(fiat)
+	if err != nil {
+		return Portfolio{}, err
+	}
+
+	return NewPortfolio(fiat, balances)
+}
+
+func NewPortfolio(fiat Currency, balances map[string][]Balance) (Portfolio, error) {
+	portfolio := Portfolio{
+		Fiat:      fiat,
+		Timestamp: time.Now(),
+		Accounts:  map[string]Holdings{},
+		Totals:    Holdings{},
+	}
+
+	for account, accountBalances := range balances {
+		holdings := Holdings{}
+
+		for _, balance := range accountBalances {
+			holding, err := NewHolding(balance)
+			if err != nil {
+				return portfolio, err
+			}
+
+			holdings.add(holding)
+			portfolio.Totals.add(holding)
+		}
+
+		portfolio.Accounts[account] = holdings
+	}
+
+	return portfolio, nil
+}
+
+func (p Portfolio) AccountNames() []string {
+	names := make([]string, 0, len(p.Accounts))
+	for name := range p.Accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (p Portfolio) Notional() float64 {
+	return p.Totals.Notional()
+}
+
+type PortfolioDiff struct {
+	Fiat     Currency
+	From     time.Time
+	To       time.Time
+	Accounts map[string]Holdings
+	Totals   Holdings
+}
+
+// Diff returns the change in holdings from prev to p. Accounts and currencies
+// that did not change are omitted.
+func (p Portfolio) Diff(prev Portfolio) PortfolioDiff {
+	diff := PortfolioDiff{
+		Fiat:     p.Fiat,
+		From:     prev.Timestamp,
+		To:       p.Timestamp,
+		Accounts: map[string]Holdings{},
+		Totals:   p.Totals.Sub(prev.Totals),
+	}
+
+	for account, holdings := range p.Accounts {
+		delta := holdings.Sub(prev.Accounts[account])
+		if len(delta) > 0 {
+			diff.Accounts[account] = delta
+		}
+	}
+
+	for account, holdings := range prev.Accounts {
+		if _, ok := p.Accounts[account]; !ok {
+			diff.Accounts[account] = Holdings{}.Sub(holdings)
+		}
+	}
+
+	return diff
+}
@@ -49,6 +49,36 @@ func (c *Client) CancelOrder(orderId uint, account *string) (Order, error) {
 	return order, err
 }
 
+func (c *Client) CancelSession() (CancelResult, error) {
+	var cancelResult CancelResult
+
+	response, err := c.PrivateRequest(CancelSessionUri, nil)
+	if err != nil {
+		return cancelResult, err
+	}
+
+	err = json.Unmarshal(response, &cancelResult)
+
+	return cancelResult, err
+}
+
+func (c *Client) CancelAll(account *string) (CancelResult, error) {
+	params := map[string]interface{}{
+		"account": account,
+	}
+
+	var cancelResult CancelResult
+
+	response, err := c.PrivateRequest(CancelAllUri, params)
+	if err != nil {
+		return cancelResult, err
+	}
+
+	err = json.Unmarshal(response, &cancelResult)
+
+	return cancelResult, err
+}
+
 func (c *Client) OrderStatus(orderId uint, clientOrderId *uint, includeTrades *bool, account *string) (Order, error) {
 	params := map[string]interface{}{
 		"order_id":        orderId,
@@ -162,7 +192,16 @@ func (c *Client) Transfers(timestamp *uint64, limitTransfers *uint, account *str
 	return transfers, err
 }
 
+// WithdrawCrypto rejects malformed addresses before sending the request,
+// for currencies whose network is known.
 func (c *Client) WithdrawCrypto(currency Currency, address string, amount string, account *string) (CryptoWithdrawal, error) {
+	if network := currency.Network(); network != "" {
+		err := c.ValidateAddress(network, address)
+		if err != nil {
+			return CryptoWithdrawal{}, err
+		}
+	}
+
 	uri := fmt.Sprintf(WithdrawCryptoUri, currency)
 
 	params := map[string]interface{}{
@@ -223,7 +262,16 @@ func (c *Client) InternalTransfer(currency Currency, sourceAccount string, targe
 	return internalTransfer, err
 }
 
+// RequestAddress rejects malformed addresses before sending the request,
+// for networks with a validator.
 func (c *Client) RequestAddress(network Network, address string, label string, account *string) (AddressRequest, error) {
+	if _, ok := c.addressValidators()[network]; ok {
+		err := c.ValidateAddress(network, address)
+		if err != nil {
+			return AddressRequest{}, err
+		}
+	}
+
 	uri := fmt.Sprintf(RequestAddressUri, network)
 
 	params := map[string]interface{}{
@@ -244,6 +292,25 @@ func (c *Client) RequestAddress(network Network, address string, label string, a
 	return addressRequest, err
 }
 
+func (c *Client) ApprovedAddresses(network Network, account *string) ([]ApprovedAddress, error) {
+	uri := fmt.Sprintf(ApprovedAddressesUri, network)
+
+	params := map[string]interface{}{
+		"account": account,
+	}
+
+	var approvedAddresses ApprovedAddresses
+
+	response, err := c.PrivateRequest(uri, params)
+	if err != nil {
+		return approvedAddresses.ApprovedAddresses, err
+	}
+
+	err = json.Unmarshal(response, &approvedAddresses)
+
+	return approvedAddresses.ApprovedAddresses, err
+}
+
 func (c *Client) AccountDetail(account *string) (AccountDetail, error) {
 	params := map[string]interface{}{
 		"account": account,
@@ -3,6 +3,7 @@ package geminix
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 )
 
 func (c *Client) Ticker(symbol Symbol) (Ticker, error) {
@@ -19,3 +20,70 @@ func (c *Client) Ticker(symbol Symbol) (Ticker, error) {
 
 	return ticker, err
 }
+
+func (c *Client) OrderBook(symbol Symbol, limitBids *uint, limitAsks *uint) (OrderBook, error) {
+	uri := fmt.Sprintf(OrderBookUri, symbol)
+
+	params := map[string]interface{}{}
+	if limitBids != nil {
+		params["limit_bids"] = strconv.FormatUint(uint64(*limitBids), 10)
+	}
+	if limitAsks != nil {
+		params["limit_asks"] = strconv.FormatUint(uint64(*limitAsks), 10)
+	}
+
+	var orderBook OrderBook
+
+	response, err := c.Request("GET", uri, params)
+	if err != nil {
+		return orderBook, err
+	}
+
+	err = json.Unmarshal(response, &orderBook)
+
+	return orderBook, err
+}
+
+func (c *Client) Trades(symbol Symbol, timestamp *uint64, sinceTid *uint, limitTrades *uint, includeBreaks *bool) ([]Trade, error) {
+	uri := fmt.Sprintf(TradesUri, symbol)
+
+	params := map[string]interface{}{}
+	if timestamp != nil {
+		params["timestamp"] = strconv.FormatUint(*timestamp, 10)
+	}
+	if sinceTid != nil {
+		params["since_tid"] = strconv.FormatUint(uint64(*sinceTid), 10)
+	}
+	if limitTrades != nil {
+		params["limit_trades"] = strconv.FormatUint(uint64(*limitTrades), 10)
+	}
+	if includeBreaks != nil {
+		params["include_breaks"] = strconv.FormatBool(*includeBreaks)
+	}
+
+	var trades []Trade
+
+	response, err := c.Request("GET", uri, params)
+	if err != nil {
+		return trades, err
+	}
+
+	err = json.Unmarshal(response, &trades)
+
+	return trades, err
+}
+
+func (c *Client) Candles(symbol Symbol, timeFrame TimeFrame) ([]Candle, error) {
+	uri := fmt.Sprintf(CandlesUri, symbol, timeFrame)
+
+	var candles []Candle
+
+	response, err := c.PublicRequest(uri)
+	if err != nil {
+		return candles, err
+	}
+
+	err = json.Unmarshal(response, &candles)
+
+	return candles, err
+}
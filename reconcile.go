@@ -0,0 +1,206 @@
+package geminix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Discrepancy is a difference between the local records and the exchange
+// that Reconcile could not resolve by itself.
+type Discrepancy struct {
+	OrderId string
+	Reason  string
+	Message string
+}
+
+// Reasons for a Discrepancy.
+const (
+	// The exchange does not know a recorded order. Reconcile marks it
+	// rejected.
+	DiscrepancyMissing = "Missing"
+
+	// A pending order has no client order id to look it up by, so it was
+	// left pending.
+	DiscrepancyUnresolved = "Unresolved"
+
+	// A recent trade belongs to an order that is not recorded.
+	DiscrepancyUnknownTrade = "UnknownTrade"
+)
+
+type ReconcileReport struct {
+	// Orders that filled or were cancelled while they were not watched.
+	Filled    []ManagedOrder
+	Cancelled []ManagedOrder
+
+	// Live orders on the exchange that were not recorded.
+	Imported []ManagedOrder
+
+	Discrepancies []Discrepancy
+}
+
+// Reconcile brings the records of an account's orders up to date after a
+// restart. Every recorded order that is not done is compared with the
+// account's active orders, and looked up by OrderStatus if it is no longer
+// active. Active orders that are not recorded are imported. Every trade on
+// the symbols involved since the oldest of those orders was placed is added
+// to the fills of its order.
+//
+// Transition callbacks run as orders are updated. Call Restore first to load
+// the records of the previous run.
+func (m *OrderManager) Reconcile(account *string) (ReconcileReport, error) {
+	var report ReconcileReport
+
+	accountName := ""
+	if account != nil {
+		accountName = *account
+	}
+
+	active, err := m.Exchange.ActiveOrders(account)
+	if err != nil {
+		return report, err
+	}
+
+	m.mutex.Lock()
+	var records []*ManagedOrder
+	var open []ManagedOrder
+	since := m.Now()
+	symbols := map[Symbol]bool{}
+	for _, managed := range m.orders {
+		if managed.State.Done() || managed.Account != accountName {
+			continue
+		}
+		records = append(records, managed)
+		open = append(open, managed.copy())
+		symbols[managed.Symbol] = true
+		if managed.Created.Before(since) {
+			since = managed.Created
+		}
+	}
+	m.mutex.Unlock()
+
+	activeById := map[string]Order{}
+	for _, order := range active {
+		activeById[order.OrderId] = order
+	}
+
+	for i, managed := range open {
+		var order Order
+		var ok bool
+		if managed.Order.OrderId != "" {
+			order, ok = activeById[managed.Order.OrderId]
+		}
+
+		if !ok {
+			order, err = m.status(managed)
+			var apiErr *ApiError
+			switch {
+			case err == errNoOrderId:
+				report.Discrepancies = append(report.Discrepancies, Discrepancy{
+					Reason:  DiscrepancyUnresolved,
+					Message: fmt.Sprintf("pending %s order for %s %s has no client order id", managed.Side, managed.Amount, managed.Symbol),
+				})
+				continue
+			case errors.As(err, &apiErr) && apiErr.Reason == "OrderNotFound":
+				report.Discrepancies = append(report.Discrepancies, Discrepancy{
+					OrderId: managed.Order.OrderId,
+					Reason:  DiscrepancyMissing,
+					Message: apiErr.Message,
+				})
+				m.reject(records[i], apiErr.Error())
+				continue
+			case err != nil:
+				return report, err
+			}
+		}
+
+		updated := m.HandleUpdate(order)
+		delete(activeById, order.OrderId)
+
+		switch updated.State {
+		case OrderFilled:
+			report.Filled = append(report.Filled, updated)
+		case OrderCancelled:
+			report.Cancelled = append(report.Cancelled, updated)
+		}
+	}
+
+	for _, order := range active {
+		if _, ok := activeById[order.OrderId]; !ok {
+			continue
+		}
+
+		m.mutex.Lock()
+		_, known := m.byOrderId[order.OrderId]
+		m.mutex.Unlock()
+
+		updated := m.handleUpdate(order, accountName)
+		if !known {
+			report.Imported = append(report.Imported, updated)
+			symbols[updated.Symbol] = true
+			if placed := time.Unix(0, int64(order.Timestampms)*int64(time.Millisecond)); placed.Before(since) {
+				since = placed
+			}
+		}
+	}
+
+	for symbol := range symbols {
+		err = m.reconcileTrades(symbol, since, account, &report)
+		if err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+func (m *OrderManager) reject(managed *ManagedOrder, reason string) {
+	m.mutex.Lock()
+	var transitions []OrderTransition
+	if !managed.State.Done() {
+		managed.Err = reason
+		transitions = m.setState(managed, OrderRejected)
+	}
+	m.mutex.Unlock()
+
+	m.emit(transitions)
+}
+
+// reconcileTrades adds the symbol's trades since a time to the fills of
+// their orders, reporting trades of orders that are not recorded.
+func (m *OrderManager) reconcileTrades(symbol Symbol, since time.Time, account *string, report *ReconcileReport) error {
+	trades, err := TradeHistory(context.Background(), m.Exchange, symbol, since, account).All()
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, trade := range trades {
+		managed, ok := m.byOrderId[trade.OrderId]
+		if !ok {
+			report.Discrepancies = append(report.Discrepancies, Discrepancy{
+				OrderId: trade.OrderId,
+				Reason:  DiscrepancyUnknownTrade,
+				Message: fmt.Sprintf("trade %d of %s %s at %s", trade.Tid, trade.Amount, symbol, trade.Price),
+			})
+			continue
+		}
+
+		seen := false
+		for _, fill := range managed.Fills {
+			if fill.Tid == trade.Tid {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			managed.Fills = append(managed.Fills, trade)
+			managed.Updated = m.Now()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package geminix
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ReplaceResult is the outcome of ReplaceOrder. It is the link between the
+// original and its replacement, which share no identifier on the exchange.
+type ReplaceResult struct {
+	// Original is the original order once it stopped trading: cancelled,
+	// or filled if it filled before the cancellation took effect.
+	Original Order
+
+	// Replacement is the new order, or nil if nothing was left to place.
+	Replacement *Order
+}
+
+// ReplaceOrder amends an order, which Gemini cannot do natively, by
+// cancelling it and placing a replacement at newPrice. newAmount is the new
+// total size: the replacement is for newAmount less what the original
+// executed before the cancellation took effect, so a fill racing the cancel
+// is never repeated. No replacement is placed if the original filled in the
+// meantime or already executed newAmount.
+//
+// The replacement keeps the original's side, type and options. It takes
+// clientOrderId, which links it to the original on the exchange and must
+// differ from the original's so that the two orders can be told apart. Only
+// exchange limit orders can be replaced.
+//
+// ReplaceOrder works with any Exchange, so a RiskManager checks the
+// replacement and an OrderManager records it.
+func ReplaceOrder(exchange Exchange, orderId uint, newPrice string, newAmount string, clientOrderId uint, account *string) (ReplaceResult, error) {
+	var result ReplaceResult
+
+	total, err := ParseAmount(newAmount)
+	if err != nil || total <= 0 {
+		return result, fmt.Errorf("geminix: invalid amount %q", newAmount)
+	}
+
+	original, err := exchange.OrderStatus(orderId, nil, nil, account)
+	if err != nil {
+		return result, err
+	}
+	if original.ClientOrderId == strconv.FormatUint(uint64(clientOrderId), 10) {
+		return result, fmt.Errorf("geminix: replacement client order id %d is the original's", clientOrderId)
+	}
+	if original.Type != ExchangeLimit {
+		return result, fmt.Errorf("geminix: cannot replace %s order %s", original.Type, original.OrderId)
+	}
+	if !original.IsLive {
+		result.Original = original
+		return result, nil
+	}
+
+	cancelled, err := exchange.CancelOrder(orderId, account)
+	if err != nil {
+		// The order may have filled between the status and the cancel, in
+		// which case the cancel fails and there is nothing to replace.
+		var apiErr *ApiError
+		if !errors.As(err, &apiErr) {
+			return result, err
+		}
+
+		status, statusErr := exchange.OrderStatus(orderId, nil, nil, account)
+		if statusErr != nil || status.IsLive {
+			return result, err
+		}
+		result.Original = status
+		return result, nil
+	}
+	result.Original = cancelled
+
+	if !cancelled.IsCancelled {
+		return result, nil
+	}
+
+	executed, err := ParseAmount(cancelled.ExecutedAmount)
+	if err != nil {
+		return result, err
+	}
+	remaining := subtractAmounts(newAmount, total, cancelled.ExecutedAmount, executed)
+	if remaining == "" {
+		return result, nil
+	}
+
+	var options *[]string
+	if len(cancelled.Options) > 0 {
+		list := append([]string(nil), cancelled.Options...)
+		options = &list
+	}
+
+	replacement, err := exchange.NewOrder(&clientOrderId, Symbol(cancelled.Symbol), remaining, nil, newPrice, cancelled.Side, cancelled.Type, options, nil, account)
+	if err != nil {
+		return result, err
+	}
+	result.Replacement = &replacement
+
+	return result, nil
+}
+
+// ReplaceOrder amends an order by cancelling it and placing a replacement.
+// See the package level ReplaceOrder.
+func (c *Client) ReplaceOrder(orderId uint, newPrice string, newAmount string, clientOrderId uint, account *string) (ReplaceResult, error) {
+	return ReplaceOrder(c, orderId, newPrice, newAmount, clientOrderId, account)
+}
+
+// subtractAmounts returns a minus b rounded to the decimal places of the
+// operands, so that 1.3 less 1.1 is 0.2, or an empty string if the result is
+// not positive.
+func subtractAmounts(a string, aValue float64, b string, bValue float64) string {
+	difference := aValue - bValue
+	if difference <= 0 {
+		return ""
+	}
+
+	places := decimalPlaces(a)
+	if p := decimalPlaces(b); p > places {
+		places = p
+	}
+
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(difference, 'f', places, 64), 64)
+	if rounded <= 0 {
+		return ""
+	}
+	return FormatAmount(rounded)
+}
+
+func decimalPlaces(amount string) int {
+	i := strings.IndexByte(amount, '.')
+	if i < 0 {
+		return 0
+	}
+	return len(amount) - i - 1
+}
@@ -0,0 +1,242 @@
+package geminix
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// RiskError is returned when an order fails a pre-trade check. The order is
+// never sent to the exchange.
+type RiskError struct {
+	Reason  string
+	Message string
+}
+
+func (e *RiskError) Error() string {
+	return fmt.Sprintf("[%v] %v", e.Reason, e.Message)
+}
+
+func riskError(reason string, format string, args ...interface{}) *RiskError {
+	return &RiskError{Reason: reason, Message: fmt.Sprintf(format, args...)}
+}
+
+// RiskLimits configures the checks applied to every new order. Zero values
+// disable a check.
+type RiskLimits struct {
+	// MaxOrderNotional caps price times amount, keyed by quote currency.
+	// When set, orders in quote currencies without a limit are rejected.
+	MaxOrderNotional map[Currency]float64
+
+	// MaxPosition caps the balance of a currency that an order may lead
+	// to, including open orders on the same symbol and side. Buys are
+	// checked against the base currency and sells against the quote
+	// currency they bring in.
+	MaxPosition map[Currency]float64
+
+	// PriceCollarBps rejects buys priced more than this many basis points
+	// above the ask and sells priced more than this below the bid.
+	PriceCollarBps float64
+
+	// MaxOpenOrders caps the number of live orders per symbol.
+	MaxOpenOrders int
+}
+
+// RiskManager wraps an Exchange and checks orders against its limits before
+// they are placed. Orders are checked and placed one at a time so that
+// concurrent orders cannot together exceed a limit. Every other method is
+// passed through to the wrapped exchange.
+type RiskManager struct {
+	Exchange
+
+	limits RiskLimits
+
+	mutex  sync.Mutex
+	killed bool
+	reason string
+}
+
+var _ Exchange = (*RiskManager)(nil)
+
+func NewRiskManager(exchange Exchange, limits RiskLimits) *RiskManager {
+	return &RiskManager{Exchange: exchange, limits: limits}
+}
+
+func (r *RiskManager) NewOrder(clientOrderId *uint, symbol Symbol, amount string, minAmount *string, price string, side string, Type string, options *[]string, stopPrice *string, account *string) (Order, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	err := r.check(symbol, amount, price, side, account)
+	if err != nil {
+		return Order{}, err
+	}
+
+	return r.Exchange.NewOrder(clientOrderId, symbol, amount, minAmount, price, side, Type, options, stopPrice, account)
+}
+
+// Check applies the pre-trade checks to an order without placing it.
+func (r *RiskManager) Check(symbol Symbol, amount string, price string, side string, account *string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.check(symbol, amount, price, side, account)
+}
+
+func (r *RiskManager) check(symbol Symbol, amount string, price string, side string, account *string) error {
+	if r.killed {
+		return riskError("KillSwitch", "trading is halted: %s", r.reason)
+	}
+
+	symbol = normalizeSymbol(symbol)
+	base, quote := symbol.Split()
+	if base == "" {
+		return riskError("UnknownSymbol", "cannot determine the currencies of %s", symbol)
+	}
+
+	amountValue, err := ParseAmount(amount)
+	if err != nil || amountValue <= 0 {
+		return riskError("InvalidAmount", "invalid order amount %q", amount)
+	}
+	priceValue, err := ParseAmount(price)
+	if err != nil || priceValue <= 0 {
+		return riskError("InvalidPrice", "invalid order price %q", price)
+	}
+	if side != Buy && side != Sell {
+		return riskError("InvalidSide", "invalid order side %q", side)
+	}
+
+	if r.limits.MaxOrderNotional != nil {
+		limit, ok := r.limits.MaxOrderNotional[quote]
+		if !ok {
+			return riskError("NoNotionalLimit", "no order notional limit is configured for %s", quote)
+		}
+		if notional := amountValue * priceValue; notional > limit {
+			return riskError("MaxOrderNotional", "order notional %s %s exceeds the limit of %s",
+				FormatAmount(notional), quote, FormatAmount(limit))
+		}
+	}
+
+	if r.limits.PriceCollarBps > 0 {
+		err = r.checkCollar(symbol, priceValue, side)
+		if err != nil {
+			return err
+		}
+	}
+
+	// A buy adds to the base currency position and a sell to the quote
+	// currency position.
+	position, increase := base, amountValue
+	if side == Sell {
+		position, increase = quote, amountValue*priceValue
+	}
+	positionLimit, hasPositionLimit := r.limits.MaxPosition[position]
+
+	if r.limits.MaxOpenOrders == 0 && !hasPositionLimit {
+		return nil
+	}
+
+	orders, err := r.Exchange.ActiveOrders(account)
+	if err != nil {
+		return err
+	}
+
+	var open int
+	var pending float64
+	for _, o := range orders {
+		if normalizeSymbol(Symbol(o.Symbol)) != symbol {
+			continue
+		}
+		open++
+		if o.Side == side {
+			remaining, _ := ParseAmount(o.RemainingAmount)
+			if side == Sell {
+				orderPrice, _ := ParseAmount(o.Price)
+				remaining *= orderPrice
+			}
+			pending += remaining
+		}
+	}
+
+	if r.limits.MaxOpenOrders > 0 && open >= r.limits.MaxOpenOrders {
+		return riskError("MaxOpenOrders", "%d orders are already open on %s", open, symbol)
+	}
+
+	if hasPositionLimit {
+		balances, err := r.Exchange.Balances(account)
+		if err != nil {
+			return err
+		}
+
+		var current float64
+		for _, b := range balances {
+			if Currency(strings.ToUpper(string(b.Currency))) == position {
+				current, _ = ParseAmount(b.Amount)
+			}
+		}
+
+		if total := current + pending + increase; total > positionLimit {
+			return riskError("MaxPosition", "%sing %s %s would bring the %s position to %s, above the limit of %s",
+				side, amount, base, position, FormatAmount(total), FormatAmount(positionLimit))
+		}
+	}
+
+	return nil
+}
+
+func (r *RiskManager) checkCollar(symbol Symbol, price float64, side string) error {
+	ticker, err := r.Exchange.Ticker(symbol)
+	if err != nil {
+		return err
+	}
+
+	collar := r.limits.PriceCollarBps / 10000
+
+	if side == Buy {
+		ask, err := ParseAmount(ticker.Ask)
+		if err != nil || ask <= 0 {
+			return riskError("NoReferencePrice", "no ask price for %s", symbol)
+		}
+		if limit := ask * (1 + collar); price > limit {
+			return riskError("PriceCollar", "buy price %s is more than %s bps above the ask of %s",
+				FormatAmount(price), FormatAmount(r.limits.PriceCollarBps), ticker.Ask)
+		}
+	} else {
+		bid, err := ParseAmount(ticker.Bid)
+		if err != nil || bid <= 0 {
+			return riskError("NoReferencePrice", "no bid price for %s", symbol)
+		}
+		if limit := bid * (1 - collar); price < limit {
+			return riskError("PriceCollar", "sell price %s is more than %s bps below the bid of %s",
+				FormatAmount(price), FormatAmount(r.limits.PriceCollarBps), ticker.Bid)
+		}
+	}
+
+	return nil
+}
+
+// Kill halts trading and cancels every open order on the account. New
+// orders are rejected until Reset is called.
+func (r *RiskManager) Kill(reason string, account *string) (CancelResult, error) {
+	r.mutex.Lock()
+	r.killed = true
+	r.reason = reason
+	r.mutex.Unlock()
+
+	return r.Exchange.CancelAll(account)
+}
+
+func (r *RiskManager) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.killed = false
+	r.reason = ""
+}
+
+// Killed reports whether trading is halted, and why.
+func (r *RiskManager) Killed() (bool, string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.killed, r.reason
+}
@@ -0,0 +1,156 @@
+package geminix
+
+type AccountScope struct {
+	client  *Client
+	account string
+}
+
+func (c *Client) ForAccount(account string) *AccountScope {
+	return &AccountScope{client: c, account: account}
+}
+
+func (s *AccountScope) Account() string {
+	return s.account
+}
+
+func (s *AccountScope) NewOrder(clientOrderId *uint, symbol Symbol, amount string, minAmount *string, price string, side string, Type string, options *[]string, stopPrice *string) (Order, error) {
+	return s.client.NewOrder(clientOrderId, symbol, amount, minAmount, price, side, Type, options, stopPrice, &s.account)
+}
+
+func (s *AccountScope) CancelOrder(orderId uint) (Order, error) {
+	return s.client.CancelOrder(orderId, &s.account)
+}
+
+func (s *AccountScope) ReplaceOrder(orderId uint, newPrice string, newAmount string, clientOrderId uint) (ReplaceResult, error) {
+	return s.client.ReplaceOrder(orderId, newPrice, newAmount, clientOrderId, &s.account)
+}
+
+func (s *AccountScope) OrderStatus(orderId uint, clientOrderId *uint, includeTrades *bool) (Order, error) {
+	return s.client.OrderStatus(orderId, clientOrderId, includeTrades, &s.account)
+}
+
+func (s *AccountScope) ActiveOrders() ([]Order, error) {
+	return s.client.ActiveOrders(&s.account)
+}
+
+func (s *AccountScope) PastTrades(symbol Symbol, limitTrades *uint, timestamp *uint64) ([]Trade, error) {
+	return s.client.PastTrades(symbol, limitTrades, timestamp, &s.account)
+}
+
+func (s *AccountScope) Balances() ([]Balance, error) {
+	return s.client.Balances(&s.account)
+}
+
+func (s *AccountScope) NotionalBalances(currency Currency) ([]Balance, error) {
+	return s.client.NotionalBalances(currency, &s.account)
+}
+
+func (s *AccountScope) Transfers(timestamp *uint64, limitTransfers *uint, completedAdvances *bool) ([]Transfer, error) {
+	return s.client.Transfers(timestamp, limitTransfers, &s.account, completedAdvances)
+}
+
+func (s *AccountScope) WithdrawCrypto(currency Currency, address string, amount string) (CryptoWithdrawal, error) {
+	return s.client.WithdrawCrypto(currency, address, amount, &s.account)
+}
+
+func (s *AccountScope) DepositAddresses(network Network) ([]DepositAddress, error) {
+	return s.client.DepositAddresses(network, &s.account)
+}
+
+func (s *AccountScope) TransferTo(currency Currency, targetAccount string, amount string) (InternalTransfer, error) {
+	return s.client.InternalTransfer(currency, s.account, targetAccount, amount)
+}
+
+func (s *AccountScope) RequestAddress(network Network, address string, label string) (AddressRequest, error) {
+	return s.client.RequestAddress(network, address, label, &s.account)
+}
+
+func (s *AccountScope) ApprovedAddresses(network Network) ([]ApprovedAddress, error) {
+	return s.client.ApprovedAddresses(network, &s.account)
+}
+
+func (s *AccountScope) AccountDetail() (AccountDetail, error) {
+	return s.client.AccountDetail(&s.account)
+}
+
+// ForEachAccount calls fn once per account returned by Accounts. Calls are made
+// sequentially so that nonces reach the exchange in increasing order.
+func (c *Client) ForEachAccount(fn func(scope *AccountScope) error) error {
+	accounts, err := c.Accounts()
+	if err != nil {
+		return err
+	}
+
+	for _, account := range accounts {
+		err = fn(c.ForAccount(account.Account))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) AllBalances() (map[string][]Balance, error) {
+	balances := map[string][]Balance{}
+
+	err := c.ForEachAccount(func(scope *AccountScope) error {
+		accountBalances, err := scope.Balances()
+		if err != nil {
+			return err
+		}
+
+		balances[scope.account] = accountBalances
+		return nil
+	})
+
+	return balances, err
+}
+
+func (c *Client) AllNotionalBalances(currency Currency) (map[string][]Balance, error) {
+	balances := map[string][]Balance{}
+
+	err := c.ForEachAccount(func(scope *AccountScope) error {
+		accountBalances, err := scope.NotionalBalances(currency)
+		if err != nil {
+			return err
+		}
+
+		balances[scope.account] = accountBalances
+		return nil
+	})
+
+	return balances, err
+}
+
+func (c *Client) AllActiveOrders() (map[string][]Order, error) {
+	orders := map[string][]Order{}
+
+	err := c.ForEachAccount(func(scope *AccountScope) error {
+		accountOrders, err := scope.ActiveOrders()
+		if err != nil {
+			return err
+		}
+
+		orders[scope.account] = accountOrders
+		return nil
+	})
+
+	return orders, err
+}
+
+func (c *Client) AllPastTrades(symbol Symbol, limitTrades *uint, timestamp *uint64) (map[string][]Trade, error) {
+	trades := map[string][]Trade{}
+
+	err := c.ForEachAccount(func(scope *AccountScope) error {
+		accountTrades, err := scope.PastTrades(symbol, limitTrades, timestamp)
+		if err != nil {
+			return err
+		}
+
+		trades[scope.account] = accountTrades
+		return nil
+	})
+
+	return trades, err
+}
@@ -0,0 +1,211 @@
+package geminix
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/rpc"
+	"os"
+	"sync"
+)
+
+// Signer produces the X-GEMINI-SIGNATURE header for a base64 encoded
+// payload. Implementations other than HMACSigner let the API secret live
+// outside the process that submits requests.
+type Signer interface {
+	Sign(payload string) (string, error)
+}
+
+type HMACSigner struct {
+	secret []byte
+}
+
+func NewHMACSigner(secret string) *HMACSigner {
+	return &HMACSigner{secret: []byte(secret)}
+}
+
+func (s *HMACSigner) Sign(payload string) (string, error) {
+	mac := hmac.New(sha512.New384, s.secret)
+	mac.Write([]byte(payload))
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func (s *HMACSigner) String() string {
+	return "HMACSigner{REDACTED}"
+}
+
+func (s *HMACSigner) GoString() string {
+	return s.String()
+}
+
+type SignArgs struct {
+	Key     string
+	Payload string
+}
+
+type SignReply struct {
+	Signature string
+}
+
+// SignerServer signs payloads on behalf of remote clients over net/rpc. It
+// holds one signer per API key, and Authorize, if set, can refuse to sign a
+// request after inspecting its decoded payload.
+type SignerServer struct {
+	Authorize func(key string, request map[string]interface{}) error
+
+	mutex   sync.RWMutex
+	signers map[string]Signer
+}
+
+func NewSignerServer() *SignerServer {
+	return &SignerServer{signers: map[string]Signer{}}
+}
+
+func (s *SignerServer) AddKey(key string, signer Signer) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.signers[key] = signer
+}
+
+// Sign is the RPC method called by RemoteSigner.
+func (s *SignerServer) Sign(args SignArgs, reply *SignReply) error {
+	s.mutex.RLock()
+	signer, ok := s.signers[args.Key]
+	s.mutex.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("geminix: signer has no secret for key %s", args.Key)
+	}
+
+	if s.Authorize != nil {
+		data, err := base64.StdEncoding.DecodeString(args.Payload)
+		if err != nil {
+			return err
+		}
+
+		var request map[string]interface{}
+		err = json.Unmarshal(data, &request)
+		if err != nil {
+			return err
+		}
+
+		err = s.Authorize(args.Key, request)
+		if err != nil {
+			return err
+		}
+	}
+
+	signature, err := signer.Sign(args.Payload)
+	if err != nil {
+		return err
+	}
+
+	reply.Signature = signature
+	return nil
+}
+
+// Serve accepts connections on listener until it is closed.
+func (s *SignerServer) Serve(listener net.Listener) error {
+	server := rpc.NewServer()
+	err := server.RegisterName("Signer", s)
+	if err != nil {
+		return err
+	}
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go server.ServeConn(conn)
+	}
+}
+
+// ListenUnix removes any stale socket at path and listens on it with
+// permissions restricted to the current user.
+func ListenUnix(path string) (net.Listener, error) {
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.Chmod(path, 0600)
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+// RemoteSigner signs payloads through a SignerServer. A dropped connection
+// is redialled once per signature.
+type RemoteSigner struct {
+	network string
+	address string
+	key     string
+
+	mutex  sync.Mutex
+	client *rpc.Client
+}
+
+func DialSigner(network string, address string, key string) (*RemoteSigner, error) {
+	s := &RemoteSigner{network: network, address: address, key: key}
+
+	client, err := rpc.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	s.client = client
+
+	return s, nil
+}
+
+func (s *RemoteSigner) Sign(payload string) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var reply SignReply
+	err := s.call(payload, &reply)
+
+	// Errors returned by the server arrive as rpc.ServerError; anything else
+	// means the connection failed.
+	if _, ok := err.(rpc.ServerError); err != nil && !ok {
+		s.client.Close()
+
+		client, dialErr := rpc.Dial(s.network, s.address)
+		if dialErr != nil {
+			return "", dialErr
+		}
+		s.client = client
+
+		err = s.call(payload, &reply)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return reply.Signature, nil
+}
+
+func (s *RemoteSigner) call(payload string, reply *SignReply) error {
+	return s.client.Call("Signer.Sign", SignArgs{Key: s.key, Payload: payload}, reply)
+}
+
+func (s *RemoteSigner) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.client.Close()
+}
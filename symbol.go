@@ -0,0 +1,33 @@
+package geminix
+
+import "strings"
+
+var quoteCurrencies = []Currency{USD, EUR, GBP, SGD, DAI, BTC, ETH, BCH, LTC}
+
+// Split returns the base and quote currencies of the symbol, e.g. BTC and USD
+// for BTCUSD. Both are empty if the quote currency is not recognised.
+func (s Symbol) Split() (Currency, Currency) {
+	upper := strings.ToUpper(string(s))
+
+	for _, quote := range quoteCurrencies {
+		if strings.HasSuffix(upper, string(quote)) && len(upper) > len(quote) {
+			return Currency(strings.TrimSuffix(upper, string(quote))), quote
+		}
+	}
+
+	return "", ""
+}
+
+func (s Symbol) Base() Currency {
+	base, _ := s.Split()
+	return base
+}
+
+func (s Symbol) Quote() Currency {
+	_, quote := s.Split()
+	return quote
+}
+
+func NewSymbol(base Currency, quote Currency) Symbol {
+	return Symbol(string(base) + string(quote))
+}
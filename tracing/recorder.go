@@ -0,0 +1,91 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RecordedSpan is a span kept in memory by a Recorder.
+type RecordedSpan struct {
+	Id         uint64
+	ParentId   uint64
+	Name       string
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]interface{}
+	Err        error
+}
+
+// Recorder is a Tracer that keeps finished spans in memory, for tests and
+// debugging.
+type Recorder struct {
+	mutex  sync.Mutex
+	nextId uint64
+	spans  []RecordedSpan
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+type recorderKey struct{}
+
+type recorderSpan struct {
+	recorder *Recorder
+	span     RecordedSpan
+}
+
+func (r *Recorder) Start(ctx context.Context, name string) (context.Context, Span) {
+	r.mutex.Lock()
+	r.nextId++
+	id := r.nextId
+	r.mutex.Unlock()
+
+	s := &recorderSpan{
+		recorder: r,
+		span: RecordedSpan{
+			Id:         id,
+			Name:       name,
+			Start:      time.Now(),
+			Attributes: map[string]interface{}{},
+		},
+	}
+	if parent, ok := ctx.Value(recorderKey{}).(*recorderSpan); ok {
+		s.span.ParentId = parent.span.Id
+	}
+
+	return context.WithValue(ctx, recorderKey{}, s), s
+}
+
+func (s *recorderSpan) SetAttribute(key string, value interface{}) {
+	s.span.Attributes[key] = value
+}
+
+func (s *recorderSpan) SetError(err error) {
+	s.span.Err = err
+}
+
+func (s *recorderSpan) End() {
+	s.span.End = time.Now()
+
+	s.recorder.mutex.Lock()
+	defer s.recorder.mutex.Unlock()
+
+	s.recorder.spans = append(s.recorder.spans, s.span)
+}
+
+// Spans returns the finished spans in the order they ended.
+func (r *Recorder) Spans() []RecordedSpan {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]RecordedSpan(nil), r.spans...)
+}
+
+func (r *Recorder) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.spans = nil
+}
@@ -0,0 +1,169 @@
+// Package tracing produces a span for every request a geminix.Client makes.
+//
+// Tracer and Span are small enough to adapt to OpenTelemetry or any other
+// tracing library. Spans are children of the span in the context given to
+// Client.WithContext, so an order can be followed from the strategy that
+// decided on it to the exchange's acknowledgement:
+//
+//	client.Use(tracing.Interceptor(tracer))
+//	ctx, span := tracer.Start(ctx, "rebalance")
+//	order, err := client.WithContext(ctx).NewOrder(...)
+//	span.End()
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	geminix "github.com/Haakam21/gemini-exchange-go"
+)
+
+type Tracer interface {
+	// Start begins a span as a child of any span in ctx, and returns a
+	// context carrying the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+type Span interface {
+	SetAttribute(key string, value interface{})
+	SetError(err error)
+	End()
+}
+
+// Attribute keys set on request spans.
+const (
+	EndpointKey      = "gemini.endpoint"
+	VerbKey          = "http.method"
+	StatusKey        = "http.status_code"
+	SymbolKey        = "gemini.symbol"
+	AccountKey       = "gemini.account"
+	OrderIdKey       = "gemini.order_id"
+	ClientOrderIdKey = "gemini.client_order_id"
+	OutcomeKey       = "gemini.outcome"
+)
+
+// Interceptor starts a span around each request, named after its endpoint.
+// The outcome is "ok", the ApiError reason, or "error" for transport
+// failures.
+func Interceptor(tracer Tracer) geminix.Interceptor {
+	return func(req *geminix.RequestInfo, next geminix.Handler) (*geminix.ResponseInfo, error) {
+		endpoint := geminix.Endpoint(req.Uri)
+		if endpoint == "" {
+			endpoint = req.Uri
+		}
+
+		ctx, span := tracer.Start(req.Context, req.Verb+" "+endpoint)
+		defer span.End()
+
+		req.Context = ctx
+
+		span.SetAttribute(EndpointKey, endpoint)
+		span.SetAttribute(VerbKey, req.Verb)
+		setRequestAttributes(span, endpoint, req)
+
+		res, err := next(req)
+
+		if res != nil {
+			span.SetAttribute(StatusKey, res.Status)
+			setResponseAttributes(span, res.Body)
+		}
+
+		var apiErr *geminix.ApiError
+		switch {
+		case err == nil:
+			span.SetAttribute(OutcomeKey, "ok")
+		case errors.As(err, &apiErr):
+			span.SetAttribute(OutcomeKey, apiErr.Reason)
+			span.SetError(err)
+		default:
+			span.SetAttribute(OutcomeKey, "error")
+			span.SetError(err)
+		}
+
+		return res, err
+	}
+}
+
+func setRequestAttributes(span Span, endpoint string, req *geminix.RequestInfo) {
+	if symbol := param(req.Params, "symbol"); symbol != "" {
+		span.SetAttribute(SymbolKey, strings.ToUpper(symbol))
+	} else if symbol := pathSymbol(endpoint, req.Uri); symbol != "" {
+		span.SetAttribute(SymbolKey, strings.ToUpper(symbol))
+	}
+
+	if account := param(req.Params, "account"); account != "" {
+		span.SetAttribute(AccountKey, account)
+	}
+	if orderId := param(req.Params, "order_id"); orderId != "" {
+		span.SetAttribute(OrderIdKey, orderId)
+	}
+	if clientOrderId := param(req.Params, "client_order_id"); clientOrderId != "" {
+		span.SetAttribute(ClientOrderIdKey, clientOrderId)
+	}
+}
+
+// setResponseAttributes records the order id assigned by the exchange, so
+// that a NewOrder span can be joined to later activity on the order.
+func setResponseAttributes(span Span, body []byte) {
+	if len(body) == 0 || body[0] != '{' {
+		return
+	}
+
+	var order struct {
+		OrderId string `json:"order_id"`
+	}
+	if json.Unmarshal(body, &order) != nil {
+		return
+	}
+
+	if order.OrderId != "" {
+		span.SetAttribute(OrderIdKey, order.OrderId)
+	}
+}
+
+// pathSymbol extracts the symbol from public endpoints that take it in the
+// path.
+func pathSymbol(endpoint string, uri string) string {
+	switch endpoint {
+	case geminix.TickerUri, geminix.TickerV2Uri, geminix.OrderBookUri, geminix.TradesUri, geminix.CandlesUri,
+		geminix.AuctionUri, geminix.AuctionHistoryUri:
+	default:
+		return ""
+	}
+
+	templates := strings.Split(endpoint, "/")
+	segments := strings.Split(strings.SplitN(uri, "?", 2)[0], "/")
+	for i, template := range templates {
+		if template == "%s" && i < len(segments) {
+			return segments[i]
+		}
+	}
+	return ""
+}
+
+// param formats a request parameter, dereferencing the pointers used for
+// optional parameters. Missing and nil parameters are empty.
+func param(params map[string]interface{}, key string) string {
+	switch v := params[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case *string:
+		if v != nil {
+			return *v
+		}
+	case *uint:
+		if v != nil {
+			return fmt.Sprint(*v)
+		}
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
@@ -1,11 +1,19 @@
 package geminix
 
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
 type Symbol string
 
 type Currency string
 
 type Network string
 
+type TimeFrame string
+
 type Ticker struct {
 	Bid    string                 `json:"bid"`
 	Ask    string                 `json:"ask"`
@@ -13,6 +21,77 @@ type Ticker struct {
 	Volume map[string]interface{} `json:"volume"`
 }
 
+type BookEntry struct {
+	Price     string `json:"price"`
+	Amount    string `json:"amount"`
+	Timestamp string `json:"timestamp"`
+}
+
+type OrderBook struct {
+	Bids []BookEntry `json:"bids"`
+	Asks []BookEntry `json:"asks"`
+}
+
+func (t TimeFrame) Duration() time.Duration {
+	switch t {
+	case OneMinute:
+		return time.Minute
+	case FiveMinutes:
+		return 5 * time.Minute
+	case FifteenMinutes:
+		return 15 * time.Minute
+	case ThirtyMinutes:
+		return 30 * time.Minute
+	case OneHour:
+		return time.Hour
+	case SixHours:
+		return 6 * time.Hour
+	case OneDay:
+		return 24 * time.Hour
+	}
+	return 0
+}
+
+// Candle is decoded from the [time, open, high, low, close, volume] arrays
+// returned by the candles endpoint.
+type Candle struct {
+	Timestamp uint64
+	Open      float64
+	High      float64
+	Low       float64
+	Close     float64
+	Volume    float64
+}
+
+func (c *Candle) UnmarshalJSON(b []byte) error {
+	var values []float64
+	err := json.Unmarshal(b, &values)
+	if err != nil {
+		return err
+	}
+
+	if len(values) != 6 {
+		return fmt.Errorf("geminix: candle has %d values, expected 6", len(values))
+	}
+
+	c.Timestamp = uint64(values[0])
+	c.Open = values[1]
+	c.High = values[2]
+	c.Low = values[3]
+	c.Close = values[4]
+	c.Volume = values[5]
+
+	return nil
+}
+
+func (c Candle) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]float64{float64(c.Timestamp), c.Open, c.High, c.Low, c.Close, c.Volume})
+}
+
+func (c Candle) Time() time.Time {
+	return time.Unix(0, int64(c.Timestamp)*int64(time.Millisecond))
+}
+
 type Order struct {
 	OrderId           string   `json:"order_id"`
 	ClientOrderId     string   `json:"client_order_id"`
@@ -36,7 +115,18 @@ type Order struct {
 	Trades            []Trade  `json:"trades"`
 }
 
+type CancelResult struct {
+	Result  string              `json:"result"`
+	Details CancelResultDetails `json:"details"`
+}
+
+type CancelResultDetails struct {
+	CancelledOrders []uint64 `json:"cancelledOrders"`
+	CancelRejects   []uint64 `json:"cancelRejects"`
+}
+
 type Trade struct {
+	Symbol        string `json:"symbol"`
 	Price         string `json:"price"`
 	Amount        string `json:"amount"`
 	Timestamp     uint64 `json:"timestamp"`
@@ -129,6 +219,19 @@ type AddressRequest struct {
 	Message string `json:"message"`
 }
 
+type ApprovedAddress struct {
+	Network   Network `json:"network"`
+	Scope     string  `json:"scope"`
+	Label     string  `json:"label"`
+	Status    string  `json:"status"`
+	CreatedAt string  `json:"createdAt"`
+	Address   string  `json:"address"`
+}
+
+type ApprovedAddresses struct {
+	ApprovedAddresses []ApprovedAddress `json:"approvedAddresses"`
+}
+
 type AccountDetail struct {
 	Account Account `json:"account"`
 	Users   []User  `json:"users"`
@@ -0,0 +1,77 @@
+package geminix
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Valuer converts an amount of a currency into a fiat value at a point in
+// time.
+type Valuer interface {
+	Value(currency Currency, amount float64, at time.Time) (float64, error)
+}
+
+// StaticValuer values currencies at fixed per-unit prices.
+type StaticValuer map[Currency]float64
+
+func (v StaticValuer) Value(currency Currency, amount float64, at time.Time) (float64, error) {
+	price, ok := v[Currency(strings.ToUpper(string(currency)))]
+	if !ok {
+		return 0, fmt.Errorf("no price for %v", currency)
+	}
+
+	return amount * price, nil
+}
+
+// CandleValuer values currencies at the close of the candle of their fiat
+// pair that contains the requested time. Candles are fetched once per
+// currency, and again when a time after the last candle is requested, so
+// only times that the Candles endpoint still covers can be valued. Longer
+// time frames reach further back.
+type CandleValuer struct {
+	client    *Client
+	fiat      Currency
+	timeFrame TimeFrame
+	mutex     sync.Mutex
+	candles   map[Currency][]Candle
+}
+
+func NewCandleValuer(client *Client, fiat Currency, timeFrame TimeFrame) *CandleValuer {
+	return &CandleValuer{client: client, fiat: fiat, timeFrame: timeFrame, candles: map[Currency][]Candle{}}
+}
+
+func (v *CandleValuer) Value(currency Currency, amount float64, at time.Time) (float64, error) {
+	currency = Currency(strings.ToUpper(string(currency)))
+	if currency == v.fiat {
+		return amount, nil
+	}
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	duration := v.timeFrame.Duration()
+
+	candles, ok := v.candles[currency]
+	if !ok || len(candles) == 0 || !at.Before(candles[len(candles)-1].Time().Add(duration)) {
+		var err error
+		candles, err = v.client.Candles(NewSymbol(currency, v.fiat), v.timeFrame)
+		if err != nil {
+			return 0, err
+		}
+
+		candles = append([]Candle(nil), candles...)
+		sort.Slice(candles, func(i, j int) bool { return candles[i].Timestamp < candles[j].Timestamp })
+		v.candles[currency] = candles
+	}
+
+	// The candle containing at is the last one starting no later than at.
+	i := sort.Search(len(candles), func(i int) bool { return candles[i].Time().After(at) })
+	if i == 0 || !at.Before(candles[i-1].Time().Add(duration)) {
+		return 0, fmt.Errorf("no %v price at %v", currency, at.UTC().Format(time.RFC3339))
+	}
+
+	return amount * candles[i-1].Close, nil
+}
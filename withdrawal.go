@@ -0,0 +1,432 @@
+package geminix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PolicyError is returned when a withdrawal breaks the WithdrawalPolicy. It
+// is raised before anything is sent to the exchange.
+type PolicyError struct {
+	Reason  string
+	Message string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("[%v] %v", e.Reason, e.Message)
+}
+
+func policyError(reason string, format string, args ...interface{}) *PolicyError {
+	return &PolicyError{Reason: reason, Message: fmt.Sprintf(format, args...)}
+}
+
+// UncertainWithdrawalError is returned when a withdrawal request fails
+// without the exchange rejecting it, as on a timeout, so the withdrawal may
+// have been sent. Its amount stays counted towards the daily limit and a
+// proposal being approved is not restored. Call Sync before trying again,
+// so that a withdrawal that went through is not sent twice.
+type UncertainWithdrawalError struct {
+	Err error
+}
+
+func (e *UncertainWithdrawalError) Error() string {
+	return fmt.Sprintf("[WithdrawalUncertain] withdrawal may have been sent (%v); sync before retrying", e.Err)
+}
+
+func (e *UncertainWithdrawalError) Unwrap() error {
+	return e.Err
+}
+
+type WithdrawalPolicy struct {
+	// DailyLimits caps the amount withdrawn per currency over any 24 hours.
+	// Currencies without a limit cannot be withdrawn.
+	DailyLimits map[Currency]float64 `json:"daily_limits"`
+
+	// Allowlist restricts each currency to the listed destinations. A nil
+	// Allowlist allows any destination.
+	Allowlist map[Currency][]string `json:"allowlist,omitempty"`
+
+	// RequireApprovedAddress checks that the destination is an active
+	// approved address on the exchange.
+	RequireApprovedAddress bool `json:"require_approved_address"`
+
+	// RequireApproval makes WithdrawCrypto fail, so that every withdrawal
+	// must go through Propose and Approve.
+	RequireApproval bool `json:"require_approval"`
+
+	// ValidateAddress defaults to the client's ValidateAddress, which only
+	// accepts testnet addresses when the client uses the sandbox.
+	ValidateAddress func(network Network, address string) error `json:"-"`
+}
+
+type WithdrawalProposal struct {
+	Id         string    `json:"id"`
+	Currency   Currency  `json:"currency"`
+	Address    string    `json:"address"`
+	Amount     string    `json:"amount"`
+	Account    *string   `json:"account,omitempty"`
+	ProposedAt time.Time `json:"proposed_at"`
+}
+
+// withdrawalRecord is a withdrawal counted towards a daily limit. eid is
+// zero for withdrawals sent by the guard until Sync matches them with the
+// account's transfers.
+type withdrawalRecord struct {
+	at      time.Time
+	amount  float64
+	address string
+	eid     uint
+}
+
+// WithdrawalGuard sends withdrawals through a client only once they satisfy
+// its policy. Daily usage is tracked in memory; call Sync at startup so that
+// withdrawals made by earlier processes count towards the limits.
+type WithdrawalGuard struct {
+	// Now defaults to time.Now.
+	Now func() time.Time
+
+	client *Client
+	policy WithdrawalPolicy
+
+	mutex     sync.Mutex
+	records   map[Currency][]withdrawalRecord
+	proposals map[string]WithdrawalProposal
+	nextId    uint64
+}
+
+func NewWithdrawalGuard(client *Client, policy WithdrawalPolicy) *WithdrawalGuard {
+	if policy.ValidateAddress == nil {
+		policy.ValidateAddress = client.ValidateAddress
+	}
+
+	return &WithdrawalGuard{
+		Now:       time.Now,
+		client:    client,
+		policy:    policy,
+		records:   map[Currency][]withdrawalRecord{},
+		proposals: map[string]WithdrawalProposal{},
+	}
+}
+
+// Sync counts the crypto withdrawals in the account's last 24 hours of
+// transfers towards the daily limits, so that withdrawals made by earlier
+// processes are included. Transfers already counted, including those sent
+// through the guard, are not counted again, so Sync can be called at any
+// time.
+func (g *WithdrawalGuard) Sync(ctx context.Context, account *string) error {
+	since := g.Now().Add(-24 * time.Hour)
+
+	transfers, err := g.client.TransferHistory(ctx, since, account, nil).All()
+	if err != nil {
+		return err
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	for _, transfer := range transfers {
+		if transfer.Type != "Withdrawal" || transfer.Currency.Network() == "" {
+			continue
+		}
+
+		amount, err := ParseAmount(transfer.Amount)
+		if err != nil {
+			return err
+		}
+
+		currency := normalizeCurrency(transfer.Currency)
+		if g.match(currency, transfer, amount) {
+			continue
+		}
+
+		at := time.Unix(0, int64(transfer.Timestampms)*int64(time.Millisecond))
+		g.records[currency] = append(g.records[currency], withdrawalRecord{
+			at:      at,
+			amount:  amount,
+			address: transfer.Destination,
+			eid:     transfer.EID,
+		})
+	}
+
+	return nil
+}
+
+// match reports whether a transfer is already recorded, either by its EID
+// or as a withdrawal sent by the guard of the same amount to the same
+// address, which then takes the transfer's EID.
+func (g *WithdrawalGuard) match(currency Currency, transfer Transfer, amount float64) bool {
+	records := g.records[currency]
+
+	for _, record := range records {
+		if record.eid == transfer.EID {
+			return true
+		}
+	}
+
+	for i := range records {
+		if records[i].eid == 0 && records[i].amount == amount && records[i].address == transfer.Destination {
+			records[i].eid = transfer.EID
+			return true
+		}
+	}
+
+	return false
+}
+
+// Withdrawn returns the amount withdrawn in the last 24 hours.
+func (g *WithdrawalGuard) Withdrawn(currency Currency) float64 {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	return g.withdrawn(normalizeCurrency(currency))
+}
+
+func (g *WithdrawalGuard) withdrawn(currency Currency) float64 {
+	since := g.Now().Add(-24 * time.Hour)
+
+	var total float64
+	var recent []withdrawalRecord
+	for _, record := range g.records[currency] {
+		if record.at.After(since) {
+			total += record.amount
+			recent = append(recent, record)
+		}
+	}
+	g.records[currency] = recent
+
+	return total
+}
+
+// checkLimit must be called with the mutex held.
+func (g *WithdrawalGuard) checkLimit(currency Currency, amount float64) error {
+	limit, ok := g.policy.DailyLimits[currency]
+	if !ok {
+		return policyError("NoLimit", "no daily limit is configured for %s", currency)
+	}
+
+	withdrawn := g.withdrawn(currency)
+	if withdrawn+amount > limit {
+		return policyError("DailyLimitExceeded", "withdrawing %s %s would exceed the daily limit of %s (%s already withdrawn)",
+			FormatAmount(amount), currency, FormatAmount(limit), FormatAmount(withdrawn))
+	}
+
+	return nil
+}
+
+// Check applies the policy to a withdrawal without sending it.
+func (g *WithdrawalGuard) Check(currency Currency, address string, amount string, account *string) error {
+	currency = normalizeCurrency(currency)
+
+	value, err := ParseAmount(amount)
+	if err != nil || value <= 0 {
+		return policyError("InvalidAmount", "invalid withdrawal amount %q", amount)
+	}
+
+	network := currency.Network()
+	if network == "" {
+		return policyError("InvalidCurrency", "%s has no known withdrawal network", currency)
+	}
+
+	err = g.policy.ValidateAddress(network, address)
+	if err != nil {
+		return policyError("InvalidAddress", "%v", err)
+	}
+
+	if g.policy.Allowlist != nil && !contains(g.policy.Allowlist[currency], address) {
+		return policyError("AddressNotAllowed", "%s is not on the %s allowlist", address, currency)
+	}
+
+	g.mutex.Lock()
+	err = g.checkLimit(currency, value)
+	g.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if g.policy.RequireApprovedAddress {
+		approved, err := g.client.ApprovedAddresses(network, account)
+		if err != nil {
+			return err
+		}
+
+		active := false
+		for _, a := range approved {
+			if a.Address == address && a.Status == "active" {
+				active = true
+			}
+		}
+		if !active {
+			return policyError("AddressNotApproved", "%s is not an active approved %s address", address, network)
+		}
+	}
+
+	return nil
+}
+
+// WithdrawCrypto checks and sends a withdrawal in one step. It fails if the
+// policy requires approval. A request that fails without the exchange
+// rejecting it returns an UncertainWithdrawalError.
+func (g *WithdrawalGuard) WithdrawCrypto(currency Currency, address string, amount string, account *string) (CryptoWithdrawal, error) {
+	if g.policy.RequireApproval {
+		return CryptoWithdrawal{}, policyError("ApprovalRequired", "withdrawals must be proposed and approved")
+	}
+
+	return g.withdraw(normalizeCurrency(currency), address, amount, account)
+}
+
+func (g *WithdrawalGuard) withdraw(currency Currency, address string, amount string, account *string) (CryptoWithdrawal, error) {
+	err := g.Check(currency, address, amount, account)
+	if err != nil {
+		return CryptoWithdrawal{}, err
+	}
+
+	value, _ := ParseAmount(amount)
+
+	// Reserve the amount before sending so that concurrent withdrawals cannot
+	// both pass the limit, and release it if the exchange rejects the
+	// request.
+	g.mutex.Lock()
+	err = g.checkLimit(currency, value)
+	if err != nil {
+		g.mutex.Unlock()
+		return CryptoWithdrawal{}, err
+	}
+	record := withdrawalRecord{at: g.Now(), amount: value, address: address}
+	g.records[currency] = append(g.records[currency], record)
+	g.mutex.Unlock()
+
+	withdrawal, err := g.client.WithdrawCrypto(currency, address, amount, account)
+	if err != nil {
+		var apiErr *ApiError
+		if !errors.As(err, &apiErr) {
+			return withdrawal, &UncertainWithdrawalError{Err: err}
+		}
+
+		g.mutex.Lock()
+		for i, r := range g.records[currency] {
+			if r == record {
+				g.records[currency] = append(g.records[currency][:i], g.records[currency][i+1:]...)
+				break
+			}
+		}
+		g.mutex.Unlock()
+	}
+
+	return withdrawal, err
+}
+
+// Propose checks a withdrawal and holds it until it is approved or rejected.
+func (g *WithdrawalGuard) Propose(currency Currency, address string, amount string, account *string) (WithdrawalProposal, error) {
+	currency = normalizeCurrency(currency)
+
+	err := g.Check(currency, address, amount, account)
+	if err != nil {
+		return WithdrawalProposal{}, err
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.nextId++
+	proposal := WithdrawalProposal{
+		Id:         strconv.FormatUint(g.nextId, 10),
+		Currency:   currency,
+		Address:    address,
+		Amount:     amount,
+		Account:    account,
+		ProposedAt: g.Now(),
+	}
+	g.proposals[proposal.Id] = proposal
+
+	return proposal, nil
+}
+
+// Approve sends a proposed withdrawal. The policy is checked again, since
+// limits may have been used up since the proposal was made. If the
+// withdrawal is refused, the proposal stays pending; if it may have been
+// sent, it does not (see UncertainWithdrawalError).
+func (g *WithdrawalGuard) Approve(id string) (CryptoWithdrawal, error) {
+	// The proposal is taken out while it is sent, so that it cannot be
+	// approved twice at once.
+	g.mutex.Lock()
+	proposal, ok := g.proposals[id]
+	delete(g.proposals, id)
+	g.mutex.Unlock()
+
+	if !ok {
+		return CryptoWithdrawal{}, policyError("UnknownProposal", "no pending withdrawal proposal %s", id)
+	}
+
+	withdrawal, err := g.withdraw(proposal.Currency, proposal.Address, proposal.Amount, proposal.Account)
+	var uncertain *UncertainWithdrawalError
+	if err != nil && !errors.As(err, &uncertain) {
+		g.mutex.Lock()
+		g.proposals[id] = proposal
+		g.mutex.Unlock()
+	}
+
+	return withdrawal, err
+}
+
+func (g *WithdrawalGuard) Reject(id string) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if _, ok := g.proposals[id]; !ok {
+		return policyError("UnknownProposal", "no pending withdrawal proposal %s", id)
+	}
+	delete(g.proposals, id)
+
+	return nil
+}
+
+// Proposals returns the pending proposals, oldest first.
+func (g *WithdrawalGuard) Proposals() []WithdrawalProposal {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	proposals := make([]WithdrawalProposal, 0, len(g.proposals))
+	for _, proposal := range g.proposals {
+		proposals = append(proposals, proposal)
+	}
+	sort.Slice(proposals, func(i, j int) bool {
+		a, _ := strconv.ParseUint(proposals[i].Id, 10, 64)
+		b, _ := strconv.ParseUint(proposals[j].Id, 10, 64)
+		return a < b
+	})
+
+	return proposals
+}
+
+// RestoreProposals adds proposals saved by an earlier process, such as from
+// Proposals, so that they can be approved or rejected.
+func (g *WithdrawalGuard) RestoreProposals(proposals []WithdrawalProposal) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	for _, proposal := range proposals {
+		g.proposals[proposal.Id] = proposal
+		if id, err := strconv.ParseUint(proposal.Id, 10, 64); err == nil && id > g.nextId {
+			g.nextId = id
+		}
+	}
+}
+
+func normalizeCurrency(currency Currency) Currency {
+	return Currency(strings.ToUpper(string(currency)))
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}